@@ -9,12 +9,14 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
-var (
-	AuthToken = fetchToken(context.Background(), ClientID, ClientSecret)
-)
+// AuthToken is fetched on first use, so code paths that never call the API need no credentials.
+var AuthToken = sync.OnceValue(func() *TokenResponse {
+	return fetchToken(context.Background(), ClientID, ClientSecret)
+})
 
 // TokenResponse models the osu! OAuth token response.
 type TokenResponse struct {
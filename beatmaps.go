@@ -187,7 +187,7 @@ func tryFetchBeatmaps(ctx context.Context, ids []int) ([]Beatmap, error) {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", AuthToken.TokenType+" "+AuthToken.AccessToken)
+	req.Header.Set("Authorization", AuthToken().TokenType+" "+AuthToken().AccessToken)
 	req.Header.Set("Content-Type", "application/json")
 
 	// Send the request
@@ -0,0 +1,231 @@
+package dotosu
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	MIN_BEAT_LENGTH = 6
+	MAX_BEAT_LENGTH = 60000
+)
+
+// ---------- lazer ControlPointInfo ----------
+
+type TimingControlPoint struct {
+	Time             float64
+	BeatLength       float64
+	TimeSignature    int
+	OmitFirstBarLine bool
+}
+
+// BPM is 60000 / BeatLength.
+func (p TimingControlPoint) BPM() float64 { return 60000 / p.BeatLength }
+
+type DifficultyControlPoint struct {
+	Time           float64
+	SliderVelocity float64
+	GenerateTicks  bool // false for lines with a NaN beat length
+}
+
+type EffectControlPoint struct {
+	Time        float64
+	Kiai        bool
+	ScrollSpeed float64
+}
+
+type SampleControlPoint struct {
+	Time             float64
+	SampleSet        string
+	CustomSampleBank int
+	Volume           int
+}
+
+var (
+	DefaultTimingPoint     = TimingControlPoint{BeatLength: 1000, TimeSignature: 4}
+	DefaultDifficultyPoint = DifficultyControlPoint{SliderVelocity: 1, GenerateTicks: true}
+	DefaultEffectPoint     = EffectControlPoint{ScrollSpeed: 1}
+	DefaultSamplePoint     = SampleControlPoint{SampleSet: "normal", Volume: 100}
+)
+
+// ControlPoints splits the [TimingPoints] lines into per-kind lists, the way lazer's legacy
+// decoder does:
+//   - lines are ordered by time, red lines before green lines at the same time, so a green
+//     line always overrides the red one it shares a time with;
+//   - every line, red or green, yields a difficulty, effect and sample point. Red lines
+//     therefore reset the slider velocity to 1;
+//   - a point equal to the one already active is redundant and dropped, a later point at the
+//     same time replaces the earlier one.
+//
+// Before the first red line the first red line applies; the other kinds use their defaults
+// before their first point, so green lines placed before the first red line still count.
+type ControlPoints struct {
+	Timing     []TimingControlPoint
+	Difficulty []DifficultyControlPoint
+	Effect     []EffectControlPoint
+	Sample     []SampleControlPoint
+}
+
+func NewControlPoints(timingPoints []TimingPoint) *ControlPoints {
+	lines := make([]TimingPoint, len(timingPoints))
+	copy(lines, timingPoints)
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].Time != lines[j].Time {
+			return lines[i].Time < lines[j].Time
+		}
+		return lines[i].TimingChange && !lines[j].TimingChange
+	})
+
+	cp := &ControlPoints{}
+	for _, tp := range lines {
+		t := float64(tp.Time)
+		if tp.TimingChange {
+			beatLength := tp.BeatLength
+			if !math.IsNaN(beatLength) {
+				beatLength = clampFloat(beatLength, MIN_BEAT_LENGTH, MAX_BEAT_LENGTH)
+			}
+			// timing points are never redundant
+			cp.Timing = addControlPoint(cp.Timing, TimingControlPoint{
+				Time:             t,
+				BeatLength:       beatLength,
+				TimeSignature:    tp.TimeSignature,
+				OmitFirstBarLine: tp.OmitFirstBarSignature,
+			}, func(a, b TimingControlPoint) bool { return false })
+		}
+
+		sv := 1.0
+		if tp.BeatLength < 0 {
+			sv = 100 / -tp.BeatLength
+		}
+		cp.Difficulty = addControlPoint(cp.Difficulty, DifficultyControlPoint{
+			Time:           t,
+			SliderVelocity: clampFloat(sv, 0.1, 10),
+			GenerateTicks:  !math.IsNaN(tp.BeatLength),
+		}, func(a, b DifficultyControlPoint) bool {
+			return a.SliderVelocity == b.SliderVelocity && a.GenerateTicks == b.GenerateTicks
+		})
+
+		cp.Effect = addControlPoint(cp.Effect, EffectControlPoint{
+			Time:        t,
+			Kiai:        tp.Kiai,
+			ScrollSpeed: tp.ScrollSpeed,
+		}, func(a, b EffectControlPoint) bool {
+			return a.Kiai == b.Kiai && a.ScrollSpeed == b.ScrollSpeed
+		})
+
+		cp.Sample = addControlPoint(cp.Sample, SampleControlPoint{
+			Time:             t,
+			SampleSet:        tp.SampleSet,
+			CustomSampleBank: tp.CustomSampleBank,
+			Volume:           tp.SampleVolume,
+		}, func(a, b SampleControlPoint) bool {
+			return a.SampleSet == b.SampleSet && a.CustomSampleBank == b.CustomSampleBank && a.Volume == b.Volume
+		})
+	}
+	return cp
+}
+
+type controlPoint interface {
+	pointTime() float64
+}
+
+func (p TimingControlPoint) pointTime() float64     { return p.Time }
+func (p DifficultyControlPoint) pointTime() float64 { return p.Time }
+func (p EffectControlPoint) pointTime() float64     { return p.Time }
+func (p SampleControlPoint) pointTime() float64     { return p.Time }
+
+// addControlPoint appends p to a time ordered list, skipping it when the active point is
+// equivalent and replacing the active point when it is at the same time.
+func addControlPoint[T controlPoint](list []T, p T, equivalent func(a, b T) bool) []T {
+	if n := len(list); n > 0 {
+		last := list[n-1]
+		if equivalent(p, last) {
+			return list
+		}
+		if last.pointTime() == p.pointTime() {
+			list[n-1] = p
+			return list
+		}
+	}
+	return append(list, p)
+}
+
+// pointAt returns the index of the last point at or before t, -1 if there is none.
+func pointAt[T controlPoint](list []T, t float64) int {
+	return sort.Search(len(list), func(i int) bool { return list[i].pointTime() > t }) - 1
+}
+
+// ---------- queries ----------
+
+// TimingAt returns the red line active at t, the first red line before any of them.
+func (cp *ControlPoints) TimingAt(t float64) TimingControlPoint {
+	if len(cp.Timing) == 0 {
+		return DefaultTimingPoint
+	}
+	return cp.Timing[max(0, pointAt(cp.Timing, t))]
+}
+
+func (cp *ControlPoints) DifficultyAt(t float64) DifficultyControlPoint {
+	if i := pointAt(cp.Difficulty, t); i >= 0 {
+		return cp.Difficulty[i]
+	}
+	return DefaultDifficultyPoint
+}
+
+func (cp *ControlPoints) EffectAt(t float64) EffectControlPoint {
+	if i := pointAt(cp.Effect, t); i >= 0 {
+		return cp.Effect[i]
+	}
+	return DefaultEffectPoint
+}
+
+// SampleAt returns the sample point active at t. Hit object samples are looked up at
+// their end time plus CONTROL_POINT_LENIENCY, see HitObjectSampleAt.
+func (cp *ControlPoints) SampleAt(t float64) SampleControlPoint {
+	if i := pointAt(cp.Sample, t); i >= 0 {
+		return cp.Sample[i]
+	}
+	return DefaultSamplePoint
+}
+
+// HitObjectSampleAt applies the leniency stable gave to sample points placed slightly
+// after an object (or a nested slider object) ends.
+func (cp *ControlPoints) HitObjectSampleAt(endTime float64) SampleControlPoint {
+	return cp.SampleAt(endTime + CONTROL_POINT_LENIENCY)
+}
+
+func (cp *ControlPoints) BeatLengthAt(t float64) float64 { return cp.TimingAt(t).BeatLength }
+
+func (cp *ControlPoints) SliderVelocityAt(t float64) float64 {
+	return cp.DifficultyAt(t).SliderVelocity
+}
+
+func (cp *ControlPoints) KiaiAt(t float64) bool { return cp.EffectAt(t).Kiai }
+
+// RhythmDivisors are the snaps SnapDivisorAt tells apart.
+var RhythmDivisors = []int{1, 2, 3, 4, 6, 8, 12, 16}
+
+// tickDistance returns how far offset, in ms from a red line, is from the closest 1/divisor tick.
+func tickDistance(offset, beatLength float64, divisor int) float64 {
+	step := beatLength / float64(divisor)
+	return math.Abs(offset - math.Round(offset/step)*step)
+}
+
+// SnapDivisorAt returns the smallest of RhythmDivisors that has a tick of the active red line
+// at t, or 0 when t is unsnapped from all of them.
+func (cp *ControlPoints) SnapDivisorAt(t float64) int {
+	red := cp.TimingAt(t)
+	offset := t - red.Time
+	for _, div := range RhythmDivisors {
+		if tickDistance(offset, red.BeatLength, div) <= UNSNAP_TOLERANCE_MS {
+			return div
+		}
+	}
+	return 0
+}
+
+// ControlPoints builds the control point index from TimingPoints. It is not kept on the
+// beatmap, which would go stale when TimingPoints changes; loops over the objects build it once.
+func (b *Beatmap) ControlPoints() *ControlPoints {
+	return NewControlPoints(b.TimingPoints)
+}
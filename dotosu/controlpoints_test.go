@@ -0,0 +1,84 @@
+package dotosu
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestControlPoints(t *testing.T) {
+	const m = `osu file format v14
+
+[TimingPoints]
+-500,-50,4,1,0,100,0,0
+0,500,4,1,0,100,1,0
+1000,-50,4,2,0,80,0,1
+1000,250,4,1,0,100,1,0
+2000,-100,4,2,0,80,0,1
+3000,-100,4,2,0,60,0,0
+`
+	b, err := Decode(strings.NewReader(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp := b.ControlPoints()
+
+	// before the first red line the first red line applies, but the green line already counts
+	if got := cp.BeatLengthAt(-1000); got != 500 {
+		t.Errorf("beat length before first red line: %v", got)
+	}
+	if got := cp.SliderVelocityAt(-400); got != 2 {
+		t.Errorf("sv of green line before first red line: %v", got)
+	}
+	if got := cp.SliderVelocityAt(500); got != 1 {
+		t.Errorf("red line should reset sv: %v", got)
+	}
+
+	// the green line at 1000 is listed first but still overrides the red line
+	if got := cp.BeatLengthAt(1500); got != 250 {
+		t.Errorf("beat length: %v", got)
+	}
+	if got := cp.SliderVelocityAt(1500); got != 2 || !cp.KiaiAt(1500) {
+		t.Errorf("sv %v, kiai %v", got, cp.KiaiAt(1500))
+	}
+
+	// 0 and 2000 only repeat the active sample settings, so those points are redundant
+	if len(cp.Sample) != 3 {
+		t.Errorf("got %d sample points, want 3", len(cp.Sample))
+	}
+
+	if got := cp.SampleAt(2998); got.Volume != 80 {
+		t.Errorf("sample volume: %v", got.Volume)
+	}
+	if got := cp.HitObjectSampleAt(2998); got.Volume != 60 {
+		t.Errorf("sample volume with leniency: %v", got.Volume)
+	}
+
+	// edits to the timing points show up in the next index
+	b.TimingPoints[1].BeatLength = 400
+	if got := b.ControlPoints().BeatLengthAt(500); got != 400 {
+		t.Errorf("beat length after edit: %v", got)
+	}
+}
+
+func TestSnapDivisorAt(t *testing.T) {
+	cp := NewControlPoints([]TimingPoint{{Time: 100, BeatLength: 600, TimingChange: true}})
+	tests := map[float64]int{
+		100:   1,
+		400:   2,
+		300:   3,
+		250:   4,
+		200:   6,
+		175:   8,
+		150:   12,
+		137.5: 16,
+		-500:  1, // before the red line it still applies
+		160:   0, // 1/10
+		401:   2, // within tolerance
+		403:   0,
+	}
+	for time, want := range tests {
+		if got := cp.SnapDivisorAt(time); got != want {
+			t.Errorf("at %v: got 1/%d, want 1/%d", time, got, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package dotosu
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type DecodeOptions struct {
+	// Strict makes Decode fail with a *DecodeError when any line had a problem.
+	// Otherwise the problems are kept in Beatmap.Warnings and defaults are used.
+	Strict bool
+}
+
+// Diagnostic describes one problem found while decoding.
+type Diagnostic struct {
+	Section string
+	Line    int // 1-based line number in the file
+	Raw     string
+	Reason  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("[%s] line %d: %s: %q", d.Section, d.Line, d.Reason, d.Raw)
+}
+
+type DecodeError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *DecodeError) Error() string {
+	if len(e.Diagnostics) == 1 {
+		return e.Diagnostics[0].String()
+	}
+	return fmt.Sprintf("%s (and %d more problems)", e.Diagnostics[0].String(), len(e.Diagnostics)-1)
+}
+
+var sectionNames = map[section]string{
+	secNone:         "",
+	secGeneral:      "General",
+	secEditor:       "Editor",
+	secMetadata:     "Metadata",
+	secDifficulty:   "Difficulty",
+	secVariables:    "Variables",
+	secEvents:       "Events",
+	secTimingPoints: "TimingPoints",
+	secColours:      "Colours",
+	secHitObjects:   "HitObjects",
+}
+
+// lineDecoder tracks the current line so parse helpers can report where a value came from.
+type lineDecoder struct {
+	sec         section
+	line        int
+	raw         string
+	diagnostics []Diagnostic
+}
+
+func (d *lineDecoder) warn(format string, a ...any) {
+	d.diagnostics = append(d.diagnostics, Diagnostic{
+		Section: sectionNames[d.sec],
+		Line:    d.line,
+		Raw:     d.raw,
+		Reason:  fmt.Sprintf(format, a...),
+	})
+}
+
+// int is parseInt that reports malformed values; empty values silently take the default.
+func (d *lineDecoder) int(s string, def int) int {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return def
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		d.warn("invalid integer %q", s)
+		return def
+	}
+	return v
+}
+
+func (d *lineDecoder) float(s string, def float64) float64 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return def
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		d.warn("invalid number %q", s)
+		return def
+	}
+	return v
+}
+
+func (d *lineDecoder) floatAllowNaN(s string) float64 {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "nan") {
+		return math.NaN()
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		d.warn("invalid number %q", s)
+		return math.NaN()
+	}
+	return v
+}
@@ -0,0 +1,312 @@
+package dotosu
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ---------- Public API ----------
+
+func EncodeFile(path string, b *Beatmap) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Encode(f, b); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Encode writes the beatmap as a v14 .osu file.
+// Times are written as decoded, so maps older than v5 keep their EARLY_VERSION_TIMING_OFFSET.
+func Encode(w io.Writer, b *Beatmap) error {
+	bw := bufio.NewWriter(w)
+	e := encoder{w: bw}
+
+	e.line("osu file format v%d", LATEST_VERSION)
+	e.blank()
+	e.encodeGeneral(b)
+	e.encodeEditor(b)
+	e.encodeMetadata(b)
+	e.encodeDifficulty(b)
+	e.encodeEvents(b)
+	e.encodeTimingPoints(b)
+	e.encodeColours(b)
+	e.encodeHitObjects(b)
+
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+// ---------- sections ----------
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) line(format string, a ...any) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = fmt.Fprintf(e.w, format+"\r\n", a...)
+}
+
+func (e *encoder) blank() { e.line("") }
+
+func (e *encoder) encodeGeneral(b *Beatmap) {
+	g := b.General
+	e.line("[General]")
+	e.line("AudioFilename: %s", g.AudioFilename)
+	e.line("AudioLeadIn: %d", g.AudioLeadIn)
+	e.line("PreviewTime: %d", g.PreviewTime)
+	e.line("Countdown: %d", g.Countdown)
+	e.line("SampleSet: %s", encodeSampleSetName(g.SampleSet))
+	e.line("StackLeniency: %s", formatFloat(g.StackLeniency))
+	e.line("Mode: %d", g.Mode)
+	e.line("LetterboxInBreaks: %s", formatBoolInt(g.LetterboxInBreaks))
+	e.line("SpecialStyle: %s", formatBoolInt(g.SpecialStyle))
+	e.line("WidescreenStoryboard: %s", formatBoolInt(g.WidescreenStoryboard))
+	e.line("EpilepsyWarning: %s", formatBoolInt(g.EpilepsyWarning))
+	e.line("SamplesMatchPlaybackRate: %s", formatBoolInt(g.SamplesMatchPlaybackRate))
+	e.line("SampleVolume: %d", g.SampleVolume)
+	e.line("CountdownOffset: %d", g.CountdownOffset)
+	e.blank()
+}
+
+func (e *encoder) encodeEditor(b *Beatmap) {
+	e.line("[Editor]")
+	if len(b.Bookmarks) > 0 {
+		bookmarks := make([]string, len(b.Bookmarks))
+		for i, t := range b.Bookmarks {
+			bookmarks[i] = strconv.Itoa(t)
+		}
+		e.line("Bookmarks: %s", strings.Join(bookmarks, ","))
+	}
+	e.line("DistanceSpacing: %s", formatFloat(b.Editor.DistanceSpacing))
+	e.line("BeatDivisor: %d", b.BeatDivisor)
+	e.line("GridSize: %d", b.GridSize)
+	e.line("TimelineZoom: %s", formatFloat(b.TimelineZoom))
+	e.blank()
+}
+
+func (e *encoder) encodeMetadata(b *Beatmap) {
+	m := b.Metadata
+	e.line("[Metadata]")
+	e.line("Title:%s", m.Title)
+	e.line("TitleUnicode:%s", m.TitleUnicode)
+	e.line("Artist:%s", m.Artist)
+	e.line("ArtistUnicode:%s", m.ArtistUnicode)
+	e.line("Creator:%s", m.Creator)
+	e.line("Version:%s", m.Version)
+	e.line("Source:%s", m.Source)
+	e.line("Tags:%s", m.Tags)
+	e.line("BeatmapID:%d", m.BeatmapID)
+	e.line("BeatmapSetID:%d", m.BeatmapSetID)
+	e.blank()
+}
+
+func (e *encoder) encodeDifficulty(b *Beatmap) {
+	d := b.Difficulty
+	e.line("[Difficulty]")
+	e.line("HPDrainRate:%s", formatFloat(d.HPDrainRate))
+	e.line("CircleSize:%s", formatFloat(d.CircleSize))
+	e.line("OverallDifficulty:%s", formatFloat(d.OverallDifficulty))
+	e.line("ApproachRate:%s", formatFloat(d.ApproachRate))
+	e.line("SliderMultiplier:%s", formatFloat(d.SliderMultiplier))
+	e.line("SliderTickRate:%s", formatFloat(d.SliderTickRate))
+	e.blank()
+}
+
+func (e *encoder) encodeEvents(b *Beatmap) {
+	e.line("[Events]")
+	if b.Metadata.BackgroundFile != "" {
+		e.line("0,0,\"%s\",0,0", b.Metadata.BackgroundFile)
+	}
+	if b.Metadata.VideoFile != "" {
+		e.line("Video,0,\"%s\"", b.Metadata.VideoFile)
+	}
+	for _, br := range b.Breaks {
+		e.line("2,%s,%s", formatFloat(br.Start), formatFloat(br.End))
+	}
+	e.encodeStoryboard(&b.Storyboard)
+	for _, ev := range b.UnhandledEvents {
+		e.line("%s", ev)
+	}
+	e.blank()
+}
+
+func (e *encoder) encodeTimingPoints(b *Beatmap) {
+	e.line("[TimingPoints]")
+	for _, tp := range b.TimingPoints {
+		effects := 0
+		if tp.Kiai {
+			effects |= 1
+		}
+		if tp.OmitFirstBarSignature {
+			effects |= 8
+		}
+		e.line(
+			"%d,%s,%d,%d,%d,%d,%s,%d",
+			tp.Time,
+			formatFloat(tp.BeatLength),
+			tp.TimeSignature,
+			encodeSampleSetID(tp.SampleSet),
+			tp.CustomSampleBank,
+			tp.SampleVolume,
+			formatBoolInt(tp.TimingChange),
+			effects,
+		)
+	}
+	e.blank()
+}
+
+func (e *encoder) encodeColours(b *Beatmap) {
+	c := b.Colours
+	if len(c.Combo) == 0 && c.SliderTrackOverride == nil && c.SliderBorder == nil {
+		return
+	}
+	e.line("[Colours]")
+	for i, colour := range c.Combo {
+		e.line("Combo%d : %s", i+1, formatColour(colour))
+	}
+	if c.SliderTrackOverride != nil {
+		e.line("SliderTrackOverride : %s", formatColour(*c.SliderTrackOverride))
+	}
+	if c.SliderBorder != nil {
+		e.line("SliderBorder : %s", formatColour(*c.SliderBorder))
+	}
+	e.blank()
+}
+
+func (e *encoder) encodeHitObjects(b *Beatmap) {
+	e.line("[HitObjects]")
+	for _, ho := range b.HitObjects {
+		p := ho.Pos()
+		prefix := fmt.Sprintf("%d,%d,%d,%d,%d", p.X, p.Y, ho.StartTime(), int(ho.Flags()), int(ho.HitSound()))
+		switch o := ho.(type) {
+		case Circle:
+			e.line("%s,%s", prefix, encodeHitSample(o.SampleHS))
+		case Spinner:
+			e.line("%s,%d,%s", prefix, o.EndTime, encodeHitSample(o.SampleHS))
+		case Hold:
+			e.line("%s,%d:%s", prefix, o.EndTime, encodeHitSample(o.SampleHS))
+		case Slider:
+			edgeSounds := make([]string, len(o.EdgeSounds))
+			for i, s := range o.EdgeSounds {
+				edgeSounds[i] = strconv.Itoa(int(s))
+			}
+			edgeAdds := make([]string, len(o.EdgeAdditions))
+			for i, a := range o.EdgeAdditions {
+				edgeAdds[i] = fmt.Sprintf("%d:%d", a.NormalSet, a.AdditionSet)
+			}
+			e.line(
+				"%s,%s,%d,%s,%s,%s,%s",
+				prefix,
+				encodeSliderPath(o.Path),
+				o.Slides,
+				formatFloat(o.Length),
+				strings.Join(edgeSounds, "|"),
+				strings.Join(edgeAdds, "|"),
+				encodeHitSample(o.SampleHS),
+			)
+		default:
+			if e.err == nil {
+				e.err = fmt.Errorf("unknown hit object kind %d at %d", ho.Kind(), ho.StartTime())
+			}
+		}
+	}
+}
+
+// ---------- encoding helpers ----------
+
+func formatFloat(v float64) string {
+	if math.IsNaN(v) {
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatBoolInt(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
+func formatColour(c Colour) string {
+	return fmt.Sprintf("%d,%d,%d", c.R, c.G, c.B)
+}
+
+func encodeSampleSetName(s string) string {
+	if s == "" {
+		return "Normal"
+	}
+	return strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
+}
+
+func encodeSampleSetID(s string) int {
+	switch strings.ToLower(s) {
+	case "normal":
+		return 1
+	case "soft":
+		return 2
+	case "drum":
+		return 3
+	default:
+		return 0
+	}
+}
+
+func encodeHitSample(s HitSampleSpec) string {
+	return fmt.Sprintf("%d:%d:%d:%d:%s", s.NormalSet, s.AdditionSet, s.Index, s.Volume, s.Filename)
+}
+
+// encodeSliderPath is the inverse of parseSliderPath: the head is implied by the object position,
+// and Bézier red anchors are written as a repeated control point.
+func encodeSliderPath(path SliderPath) string {
+	var points []Vec2
+	for i, seg := range path.Segments {
+		if i == 0 {
+			if len(seg.Points) > 0 {
+				points = append(points, seg.Points[1:]...)
+			}
+			continue
+		}
+		points = append(points, seg.Points...)
+	}
+
+	var typeStr string
+	switch path.Type {
+	case PathLinear:
+		typeStr = "L"
+	case PathCatmull:
+		typeStr = "C"
+	case PathPerfect:
+		typeStr = "P"
+	case PathBSpline:
+		typeStr = "B" + strconv.Itoa(path.Degree)
+	default:
+		if len(points) == 0 {
+			// bare head, written the same way it was read
+			return ""
+		}
+		typeStr = "B"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(typeStr)
+	for _, p := range points {
+		fmt.Fprintf(&sb, "|%d:%d", p.X, p.Y)
+	}
+	return sb.String()
+}
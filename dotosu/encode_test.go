@@ -0,0 +1,209 @@
+package dotosu
+
+import (
+	"bytes"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const rankedSetsDir = "../../_ranked_sets"
+
+const sampleMap = `osu file format v14
+
+[General]
+AudioFilename: audio.mp3
+AudioLeadIn: 0
+PreviewTime: 1234
+Countdown: 0
+SampleSet: Soft
+StackLeniency: 0.7
+Mode: 0
+LetterboxInBreaks: 0
+WidescreenStoryboard: 1
+
+[Editor]
+Bookmarks: 100,2000
+DistanceSpacing: 1.2
+BeatDivisor: 4
+GridSize: 8
+TimelineZoom: 1.5
+
+[Metadata]
+Title:Test
+TitleUnicode:Test
+Artist:Someone
+ArtistUnicode:Someone
+Creator:mapper
+Version:Insane
+Source:
+Tags:round trip
+BeatmapID:1
+BeatmapSetID:2
+
+[Difficulty]
+HPDrainRate:5
+CircleSize:4
+OverallDifficulty:8
+ApproachRate:9.3
+SliderMultiplier:1.8
+SliderTickRate:1
+
+[Events]
+0,0,"bg.jpg",0,0
+2,5000,7000
+Sprite,Foreground,Centre,"sb/a.png",320,240
+ F,0,0,500,0,1,0.5
+ M,1,0,,100,100
+ P,0,0,1000,A
+ L,1000,4
+  S,0,0,250,1,1.2
+ T,HitSoundClap,0,5000
+  C,0,0,100,255,255,255,255,0,0
+Animation,Overlay,TopLeft,"sb/b.png",0,0,4,50.5,LoopOnce
+Sample,1500,0,"sb/s.wav",80
+3,100,163,162,255
+
+[TimingPoints]
+0,333.333333333333,4,2,1,60,1,0
+1000,-75,4,2,0,50,0,1
+4000,NaN,4,1,0,50,0,8
+
+[Colours]
+Combo1 : 255,0,0
+Combo2 : 0,255,0,128
+SliderBorder : 10,20,30
+
+[HitObjects]
+256,192,0,5,0,0:0:0:0:
+100,100,333,2,2,B|150:50|200:100|200:100|300:100,2,250.5,2|0|8,1:2|0:0|2:0,1:0:3:70:hit.wav
+100,300,1000,6,0,L|200:300,1,100
+300,300,1500,2,0,P|350:250|400:300,1,120
+50,50,2000,2,0,C|100:100|150:50|200:100,1,180
+256,192,3000,12,4,3500,0:0:0:0:
+`
+
+func TestEncodeRoundTripSample(t *testing.T) {
+	first, err := Decode(strings.NewReader(sampleMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.HitObjects) != 6 {
+		t.Fatalf("decoded %d hit objects, want 6", len(first.HitObjects))
+	}
+	slider := first.HitObjects[1].(Slider)
+	if len(slider.Path.Segments) != 2 {
+		t.Fatalf("red anchor: got %d segments, want 2", len(slider.Path.Segments))
+	}
+	assertRoundTrip(t, first)
+}
+
+// decode -> encode -> decode over every cached ranked map, in .osz archives or the extracted
+// folders of older downloads
+func TestEncodeRoundTripCorpus(t *testing.T) {
+	if _, err := os.Stat(rankedSetsDir); err != nil {
+		t.Skipf("no ranked set cache at %s", rankedSetsDir)
+	}
+	if testing.Short() {
+		t.Skip("corpus round trip skipped in short mode")
+	}
+	maps := 0
+	err := filepath.WalkDir(rankedSetsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".osu":
+			first, err := DecodeFile(path)
+			if err != nil {
+				// maps we cannot read are not the encoder's problem
+				return nil
+			}
+			maps++
+			t.Run(path, func(t *testing.T) {
+				assertRoundTrip(t, first)
+			})
+		case ".osz":
+			osz, err := openOszFile(path)
+			if err != nil {
+				return nil
+			}
+			for _, difficulty := range osz.Difficulties {
+				if difficulty.Err != nil {
+					continue
+				}
+				maps++
+				t.Run(path+"/"+difficulty.Path, func(t *testing.T) {
+					assertRoundTrip(t, difficulty.Beatmap)
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maps == 0 {
+		t.Fatalf("no readable maps in %s", rankedSetsDir)
+	}
+}
+
+func openOszFile(path string) (*Osz, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return OpenOsz(file, info.Size())
+}
+
+func assertRoundTrip(t *testing.T, first *Beatmap) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Encode(&buf, first); err != nil {
+		t.Fatal(err)
+	}
+	second, err := Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("decoding encoded map: %v\n%s", err, buf.String())
+	}
+
+	// the encoder always writes the latest format, with early version offsets already applied
+	want := *first
+	want.FormatVersion = LATEST_VERSION
+	want.Warnings = nil
+	if len(second.Warnings) > 0 {
+		t.Fatalf("encoded map has decode warnings: %v", second.Warnings)
+	}
+	want.TimingPoints = nanToZero(first.TimingPoints)
+	second.TimingPoints = nanToZero(second.TimingPoints)
+
+	if !reflect.DeepEqual(&want, second) {
+		for i := range min(len(want.HitObjects), len(second.HitObjects)) {
+			if !reflect.DeepEqual(want.HitObjects[i], second.HitObjects[i]) {
+				t.Fatalf("hit object %d differs:\n%+v\n%+v", i, want.HitObjects[i], second.HitObjects[i])
+			}
+		}
+		t.Fatalf("beatmaps differ:\n%+v\n%+v", want, *second)
+	}
+}
+
+// NaN != NaN under reflect.DeepEqual
+func nanToZero(tps []TimingPoint) []TimingPoint {
+	out := make([]TimingPoint, len(tps))
+	copy(out, tps)
+	for i := range out {
+		if math.IsNaN(out[i].BeatLength) {
+			out[i].BeatLength = 0
+		}
+	}
+	return out
+}
@@ -0,0 +1,402 @@
+package dotosu
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// objects further than this from every allowed snap of the active red line are unsnapped
+	UNSNAP_TOLERANCE_MS = 1
+	// gaps in drain time at least this long need a break period
+	MIN_GAP_WITHOUT_BREAK_MS = 5000
+	// Overlay sprites at least this opaque hide the objects under them
+	HIDDEN_PLAYFIELD_OPACITY = 0.9
+)
+
+// SnapDivisors are the beat divisors the editor offers and ranking allows.
+var SnapDivisors = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 12, 16}
+
+type Severity uint8
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", s)
+}
+
+// Finding is one problem reported by a LintRule.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Time     float64 // ms; NaN when the finding is not tied to a point in the map
+	Message  string
+}
+
+// Timestamp formats Time the way the editor accepts it (mm:ss:mmm).
+func (f Finding) Timestamp() string {
+	if math.IsNaN(f.Time) {
+		return "--:--:---"
+	}
+	ms := int(math.Round(f.Time))
+	sign := ""
+	if ms < 0 {
+		sign = "-"
+		ms = -ms
+	}
+	return fmt.Sprintf("%s%02d:%02d:%03d", sign, ms/60000, ms/1000%60, ms%1000)
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s %s [%s] %s", f.Timestamp(), f.Severity, f.Rule, f.Message)
+}
+
+type LintRule struct {
+	Name  string
+	Check func(b *Beatmap) []Finding
+}
+
+// DefaultLintRules is what Lint runs when no rules are given.
+var DefaultLintRules = []LintRule{
+	{Name: "metadata", Check: lintMetadata},
+	OutsidePlayfieldRule(nil),
+	{Name: "overlap", Check: lintOverlaps},
+	{Name: "unsnapped", Check: lintUnsnapped},
+	{Name: "slider-length", Check: lintSliderLength},
+	{Name: "spinner-duration", Check: lintSpinnerDuration},
+	{Name: "missing-break", Check: lintMissingBreaks},
+}
+
+// Lint runs the rules (DefaultLintRules if none) and returns the findings ordered by time.
+func (b *Beatmap) Lint(rules ...LintRule) []Finding {
+	if len(rules) == 0 {
+		rules = DefaultLintRules
+	}
+	var out []Finding
+	for _, rule := range rules {
+		for _, f := range rule.Check(b) {
+			f.Rule = rule.Name
+			out = append(out, f)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		// untimed findings first
+		ti, tj := out[i].Time, out[j].Time
+		if math.IsNaN(ti) || math.IsNaN(tj) {
+			return math.IsNaN(ti) && !math.IsNaN(tj)
+		}
+		return ti < tj
+	})
+	return out
+}
+
+// MaxSeverity returns the highest severity among findings, or SeverityInfo when there are none.
+func MaxSeverity(findings []Finding) Severity {
+	s := SeverityInfo
+	for _, f := range findings {
+		s = max(s, f.Severity)
+	}
+	return s
+}
+
+// FormatFindings renders one finding per line.
+func FormatFindings(findings []Finding) string {
+	var sb strings.Builder
+	for _, f := range findings {
+		sb.WriteString(f.String())
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// ---------- rules ----------
+
+func lintMetadata(b *Beatmap) []Finding {
+	if err := b.Validate(); err != nil {
+		return []Finding{{Severity: SeverityError, Time: math.NaN(), Message: err.Error()}}
+	}
+	return nil
+}
+
+// OutsidePlayfieldRule reports heads and slider ends off the playfield; repeats go back and forth
+// between the two. path gives the slider end; without one it is taken as the last control point,
+// which is off when the declared length cuts or extends the path.
+func OutsidePlayfieldRule(path PathPosition) LintRule {
+	return LintRule{
+		Name: "outside-playfield",
+		Check: func(b *Beatmap) []Finding {
+			return lintOutsidePlayfield(b, path)
+		},
+	}
+}
+
+func lintOutsidePlayfield(b *Beatmap, path PathPosition) []Finding {
+	if b.General.Mode != ModeOsu {
+		return nil
+	}
+	controlPoints := b.ControlPoints()
+	outside := func(x, y float64) bool {
+		return x < 0 || x > 512 || y < 0 || y > 384
+	}
+	var out []Finding
+	for _, ho := range b.HitObjects {
+		if ho.Kind() == KindSpinner {
+			continue
+		}
+		p := ho.Pos()
+		if outside(float64(p.X), float64(p.Y)) {
+			out = append(out, Finding{
+				Severity: SeverityWarning,
+				Time:     float64(ho.StartTime()),
+				Message:  fmt.Sprintf("%s at (%d, %d) is outside the playfield", kindName(ho.Kind()), p.X, p.Y),
+			})
+		}
+		s, ok := ho.(Slider)
+		if !ok || len(s.Path.Segments) == 0 {
+			continue
+		}
+		var x, y float64
+		if path != nil {
+			x, y = path(s, 1)
+		} else {
+			points := s.Path.Segments[len(s.Path.Segments)-1].Points
+			x, y = float64(points[len(points)-1].X), float64(points[len(points)-1].Y)
+		}
+		if outside(x, y) {
+			out = append(out, Finding{
+				Severity: SeverityWarning,
+				Time:     float64(ho.StartTime()) + b.SliderTiming(controlPoints, s).SpanDuration,
+				Message:  fmt.Sprintf("slider end at (%.0f, %.0f) is outside the playfield", x, y),
+			})
+		}
+	}
+	return out
+}
+
+// HiddenPlayfieldRule reports objects played while an Overlay sprite hides the whole playfield.
+// set is the set .osb (nil without one), whose elements show on top of the beatmap's own, and
+// size reads the images of the set; FullscreenImages takes every image as large enough.
+func HiddenPlayfieldRule(set *Storyboard, size ImageSize) LintRule {
+	return LintRule{
+		Name: "hidden-playfield",
+		Check: func(b *Beatmap) []Finding {
+			sb := b.Storyboard
+			if set != nil {
+				sb.Elements = append(slices.Clone(b.Storyboard.Elements), set.Elements...)
+			}
+			var out []Finding
+			for _, ho := range b.playedUnder(sb.PlayfieldCover(HIDDEN_PLAYFIELD_OPACITY, size)) {
+				out = append(out, Finding{
+					Severity: SeverityError,
+					Time:     float64(ho.StartTime()),
+					Message:  fmt.Sprintf("%s is hidden by the storyboard", kindName(ho.Kind())),
+				})
+			}
+			return out
+		},
+	}
+}
+
+// lintOverlaps reports objects that start no later than the previous one, or than its last
+// judgement: a slider's last tick or repeat, or the middle of a spinner. Those leave no time
+// between two actions, which the pp calculation cannot handle.
+func lintOverlaps(b *Beatmap) []Finding {
+	if b.General.Mode == ModeMania {
+		return nil
+	}
+	controlPoints := b.ControlPoints()
+	var out []Finding
+	for i := 1; i < len(b.HitObjects); i++ {
+		prev, cur := b.HitObjects[i-1], b.HitObjects[i]
+		prevEnd := b.objectEndTime(controlPoints, prev)
+		switch {
+		case cur.StartTime() <= prev.StartTime():
+			out = append(out, Finding{
+				Severity: SeverityError,
+				Time:     float64(cur.StartTime()),
+				Message:  fmt.Sprintf("%s starts with no gap after the %s at %d", kindName(cur.Kind()), kindName(prev.Kind()), prev.StartTime()),
+			})
+		case b.firstJudgementTime(cur) <= b.lastJudgementTime(controlPoints, prev):
+			out = append(out, Finding{
+				Severity: SeverityError,
+				Time:     float64(cur.StartTime()),
+				Message:  fmt.Sprintf("%s starts before the last judgement of the %s at %d", kindName(cur.Kind()), kindName(prev.Kind()), prev.StartTime()),
+			})
+		case float64(cur.StartTime()) < prevEnd:
+			out = append(out, Finding{
+				Severity: SeverityWarning,
+				Time:     float64(cur.StartTime()),
+				Message:  fmt.Sprintf("%s starts before the %s at %d ends", kindName(cur.Kind()), kindName(prev.Kind()), prev.StartTime()),
+			})
+		}
+	}
+	return out
+}
+
+func lintUnsnapped(b *Beatmap) []Finding {
+	if len(b.TimingPoints) == 0 {
+		return nil
+	}
+	controlPoints := b.ControlPoints()
+	var out []Finding
+	check := func(t float64, what string) {
+		red := controlPoints.TimingAt(t)
+		if !(red.BeatLength > 0) {
+			return
+		}
+		if d := snapError(t-red.Time, red.BeatLength); d > UNSNAP_TOLERANCE_MS {
+			out = append(out, Finding{
+				Severity: SeverityWarning,
+				Time:     t,
+				Message:  fmt.Sprintf("%s is unsnapped by %.1fms", what, d),
+			})
+		}
+	}
+	for _, ho := range b.HitObjects {
+		name := kindName(ho.Kind())
+		check(float64(ho.StartTime()), name)
+		switch o := ho.(type) {
+		case Slider:
+			// slider ends are only as precise as the integer length the editor stores
+			check(math.Round(b.SliderTiming(controlPoints, o).EndTime), name+" end")
+		case Spinner:
+			check(float64(o.EndTime), name+" end")
+		case Hold:
+			check(float64(o.EndTime), name+" end")
+		}
+	}
+	return out
+}
+
+// snapError returns the distance in ms from offset to the closest tick of any SnapDivisors.
+func snapError(offset, beatLength float64) float64 {
+	best := math.Inf(1)
+	for _, div := range SnapDivisors {
+		best = math.Min(best, tickDistance(offset, beatLength, div))
+	}
+	return best
+}
+
+func lintSliderLength(b *Beatmap) []Finding {
+	var out []Finding
+	for _, ho := range b.HitObjects {
+		if s, ok := ho.(Slider); ok && !(s.Length > 0) {
+			out = append(out, Finding{
+				Severity: SeverityError,
+				Time:     float64(s.Time),
+				Message:  fmt.Sprintf("slider has length %s", formatFloat(s.Length)),
+			})
+		}
+	}
+	return out
+}
+
+func lintSpinnerDuration(b *Beatmap) []Finding {
+	var out []Finding
+	for _, ho := range b.HitObjects {
+		if s, ok := ho.(Spinner); ok && s.EndTime <= s.Time {
+			out = append(out, Finding{
+				Severity: SeverityError,
+				Time:     float64(s.Time),
+				Message:  fmt.Sprintf("spinner ends at %d, not after its start", s.EndTime),
+			})
+		}
+	}
+	return out
+}
+
+func lintMissingBreaks(b *Beatmap) []Finding {
+	controlPoints := b.ControlPoints()
+	var out []Finding
+	for i := 1; i < len(b.HitObjects); i++ {
+		gapStart := b.objectEndTime(controlPoints, b.HitObjects[i-1])
+		gapEnd := float64(b.HitObjects[i].StartTime())
+		if gapEnd-gapStart < MIN_GAP_WITHOUT_BREAK_MS {
+			continue
+		}
+		covered := false
+		for _, br := range b.Breaks {
+			if br.Start < gapEnd && br.End > gapStart {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			out = append(out, Finding{
+				Severity: SeverityWarning,
+				Time:     gapStart,
+				Message:  fmt.Sprintf("%.0fms gap without a break", gapEnd-gapStart),
+			})
+		}
+	}
+	return out
+}
+
+// ---------- helpers ----------
+
+func (b *Beatmap) objectEndTime(controlPoints *ControlPoints, ho HitObject) float64 {
+	switch o := ho.(type) {
+	case Slider:
+		return b.SliderTiming(controlPoints, o).EndTime
+	case Spinner:
+		return float64(o.EndTime)
+	case Hold:
+		return float64(o.EndTime)
+	}
+	return float64(ho.StartTime())
+}
+
+// firstJudgementTime is when the pp calculation first judges an object: its start, or the
+// middle of a spinner.
+func (b *Beatmap) firstJudgementTime(ho HitObject) float64 {
+	if s, ok := ho.(Spinner); ok {
+		return float64(s.Time+s.EndTime) / 2
+	}
+	return float64(ho.StartTime())
+}
+
+// lastJudgementTime is when the pp calculation last judges an object. A slider is judged at
+// its ticks, repeats and legacy last tick, not at its tail; one without any after its head is
+// judged like a circle.
+func (b *Beatmap) lastJudgementTime(controlPoints *ControlPoints, ho HitObject) float64 {
+	s, ok := ho.(Slider)
+	if !ok {
+		return b.firstJudgementTime(ho)
+	}
+	last := float64(s.Time)
+	for _, e := range b.SliderEvents(controlPoints, s) {
+		if e.Type != SliderEventHead && e.Type != SliderEventTail {
+			last = math.Max(last, e.Time)
+		}
+	}
+	return last
+}
+
+func kindName(k ObjectKind) string {
+	switch k {
+	case KindCircle:
+		return "circle"
+	case KindSlider:
+		return "slider"
+	case KindSpinner:
+		return "spinner"
+	case KindHold:
+		return "hold"
+	}
+	return "object"
+}
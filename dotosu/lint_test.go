@@ -0,0 +1,148 @@
+package dotosu
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	const m = `osu file format v14
+
+[General]
+AudioFilename: audio.mp3
+
+[Metadata]
+Title:t
+Artist:a
+
+[Difficulty]
+SliderMultiplier:1
+SliderTickRate:1
+
+[TimingPoints]
+0,500,4,2,0,100,1,0
+
+[HitObjects]
+600,192,0,1,0
+256,192,250,1,0
+256,192,250,1,0
+256,192,1003,1,0
+256,192,1500,2,0,L|356:192,1,0
+256,192,2000,12,0,2000
+256,192,9000,1,0
+`
+	b, err := Decode(strings.NewReader(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := b.Lint()
+	want := []struct {
+		rule string
+		time float64
+	}{
+		{"outside-playfield", 0},
+		{"overlap", 250},
+		{"unsnapped", 1003},
+		{"slider-length", 1500},
+		{"spinner-duration", 2000},
+		{"missing-break", 2000},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("got %d findings, want %d:\n%s", len(findings), len(want), FormatFindings(findings))
+	}
+	for i, w := range want {
+		if findings[i].Rule != w.rule || findings[i].Time != w.time {
+			t.Errorf("finding %d: got %s, want %s at %v", i, findings[i], w.rule, w.time)
+		}
+	}
+	if MaxSeverity(findings) != SeverityError {
+		t.Errorf("max severity %s", MaxSeverity(findings))
+	}
+	if ts := findings[len(findings)-1].Timestamp(); ts != "00:02:000" {
+		t.Errorf("timestamp %q", ts)
+	}
+}
+
+func TestLintSliderEnds(t *testing.T) {
+	const m = `osu file format v14
+
+[Difficulty]
+SliderMultiplier:1
+SliderTickRate:1
+
+[TimingPoints]
+0,500,4,2,0,100,1,0
+
+[HitObjects]
+400,192,0,2,0,L|600:192,2,200
+100,192,2000,2,0,L|300:192,1,200
+`
+	b, err := Decode(strings.NewReader(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := OutsidePlayfieldRule(nil)
+	findings := b.Lint(rule)
+	// the first slider reaches its end, and repeats there, after one span of 1000ms
+	if len(findings) != 1 || findings[0].Time != 1000 {
+		t.Fatalf("findings:\n%s", FormatFindings(findings))
+	}
+
+	// a path that bends the second slider up past the top edge
+	bent := func(s Slider, progress float64) (float64, float64) {
+		if s.Time == 2000 {
+			return float64(s.PosXY.X) + progress*200, float64(s.PosXY.Y) - progress*200
+		}
+		return float64(s.PosXY.X) + progress*s.Length, float64(s.PosXY.Y)
+	}
+	if findings := b.Lint(OutsidePlayfieldRule(bent)); len(findings) != 2 || findings[1].Time != 3000 {
+		t.Errorf("findings with path:\n%s", FormatFindings(findings))
+	}
+}
+
+func TestLintHiddenPlayfield(t *testing.T) {
+	const m = `osu file format v14
+
+[Events]
+Sprite,Overlay,Centre,"black.png",320,240
+ F,0,1000,2000,1
+Sprite,Overlay,Centre,"dim.png",320,240
+ F,0,4000,5000,0.5
+
+[TimingPoints]
+0,500,4,2,0,100,1,0
+
+[HitObjects]
+256,192,500,1,0
+256,192,1500,1,0
+256,192,3000,1,0
+256,192,4500,1,0
+`
+	b, err := Decode(strings.NewReader(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := b.Lint(HiddenPlayfieldRule(nil, FullscreenImages))
+	if len(findings) != 1 || findings[0].Time != 1500 || findings[0].Severity != SeverityError {
+		t.Fatalf("findings:\n%s", FormatFindings(findings))
+	}
+
+	// the set .osb covers the third object
+	set, err := DecodeStoryboard(strings.NewReader("[Events]\nSprite,Overlay,Centre,\"black.png\",320,240\n F,0,2500,3500,1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings = b.Lint(HiddenPlayfieldRule(set, FullscreenImages))
+	if len(findings) != 2 || findings[1].Time != 3000 {
+		t.Fatalf("findings with the set storyboard:\n%s", FormatFindings(findings))
+	}
+	if len(b.Storyboard.Elements) != 2 {
+		t.Errorf("the set storyboard was appended to the beatmap's: %d elements", len(b.Storyboard.Elements))
+	}
+
+	// a logo too small to cover the playfield
+	small := func(string) (float64, float64, bool) { return 100, 100, true }
+	if findings := b.Lint(HiddenPlayfieldRule(set, small)); len(findings) != 0 {
+		t.Errorf("findings with small images:\n%s", FormatFindings(findings))
+	}
+}
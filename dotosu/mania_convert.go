@@ -0,0 +1,869 @@
+package dotosu
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// ---------- lazer ManiaBeatmapConverter for osu!standard maps ----------
+
+// legacyRandom is stable's xorshift generator, lazer LegacyRandom. Converts depend on its exact
+// sequence.
+type legacyRandom struct {
+	x, y, z, w uint32
+}
+
+func newLegacyRandom(seed int32) *legacyRandom {
+	return &legacyRandom{x: uint32(seed), y: 842502087, z: 3579807591, w: 273326509}
+}
+
+func (r *legacyRandom) nextUint() uint32 {
+	t := r.x ^ (r.x << 11)
+	r.x, r.y, r.z = r.y, r.z, r.w
+	r.w = r.w ^ (r.w >> 19) ^ t ^ (t >> 8)
+	return r.w
+}
+
+func (r *legacyRandom) nextDouble() float64 {
+	return float64(r.nextUint()&math.MaxInt32) / (math.MaxInt32 + 1.0)
+}
+
+// nextRange returns an int in [lower, upper).
+func (r *legacyRandom) nextRange(lower, upper int) int {
+	return int(float64(lower) + r.nextDouble()*float64(upper-lower))
+}
+
+// lazer PatternType
+type patternType uint16
+
+const (
+	patternForceStack patternType = 1 << iota
+	patternForceNotStack
+	patternKeepSingle
+	patternLowProbability
+	patternAlternate
+	patternForceSigSlider
+	patternForceNotSlider
+	patternGathered
+	patternMirror
+	patternReverse
+	patternCycle
+	patternStair
+	patternReverseStair
+)
+
+func (t patternType) has(flag patternType) bool { return t&flag != 0 }
+
+// maniaPattern is the notes one object converts to, lazer Pattern.
+type maniaPattern struct {
+	notes   []ManiaNote
+	columns uint32 // bit i is set when column i has a note
+}
+
+func (p *maniaPattern) add(note ManiaNote) {
+	p.notes = append(p.notes, note)
+	p.columns |= 1 << note.Column
+}
+
+func (p *maniaPattern) addPattern(other maniaPattern) {
+	for _, note := range other.notes {
+		p.add(note)
+	}
+}
+
+func (p maniaPattern) hasColumn(column int) bool { return p.columns&(1<<column) != 0 }
+func (p maniaPattern) columnsWithNotes() int     { return bits.OnesCount32(p.columns) }
+
+// errNotEnoughColumns is lazer's NotEnoughColumnsException: no column is left for a note.
+var errNotEnoughColumns = errors.New("mania convert: not enough columns")
+
+// patternGenerator holds what lazer's LegacyPatternGenerator shares between object kinds.
+type patternGenerator struct {
+	random               *legacyRandom
+	object               HitObject
+	previous             maniaPattern
+	totalColumns         int
+	randomStart          int // the special column of 8K is only placed on purpose
+	conversionDifficulty float64
+}
+
+// findAvailableColumn starts at initial and moves with next, a random column of
+// [lower, upper) when nil, until the column passes valid and none of patterns has a note in it.
+func (g *patternGenerator) findAvailableColumn(
+	initial, lower, upper int,
+	next func(int) int,
+	valid func(int) bool,
+	patterns ...*maniaPattern,
+) int {
+	if next == nil {
+		next = func(int) int { return g.random.nextRange(lower, upper) }
+	}
+	isValid := func(column int) bool {
+		if valid != nil && !valid(column) {
+			return false
+		}
+		for _, p := range patterns {
+			if p.hasColumn(column) {
+				return false
+			}
+		}
+		return true
+	}
+	if isValid(initial) {
+		return initial
+	}
+	hasValid := false
+	for i := lower; i < upper && !hasValid; i++ {
+		hasValid = isValid(i)
+	}
+	if !hasValid {
+		panic(errNotEnoughColumns)
+	}
+	for {
+		initial = next(initial)
+		if isValid(initial) {
+			return initial
+		}
+	}
+}
+
+// availableColumn is findAvailableColumn over all columns with random steps.
+func (g *patternGenerator) availableColumn(initial int, patterns ...*maniaPattern) int {
+	return g.findAvailableColumn(initial, g.randomStart, g.totalColumns, nil, nil, patterns...)
+}
+
+func (g *patternGenerator) randomColumn() int {
+	return g.random.nextRange(g.randomStart, g.totalColumns)
+}
+
+// randomNoteCount returns 1 + how many of p2, p3, ... a roll passes, checking the largest first.
+func (g *patternGenerator) randomNoteCount(p ...float64) int {
+	val := g.random.nextDouble()
+	for n := len(p); n > 0; n-- {
+		if val >= 1-p[n-1] {
+			return n + 1
+		}
+	}
+	return 1
+}
+
+// column maps an X position to a column; allowSpecial keeps the first column of 8K out of it.
+func (g *patternGenerator) column(x float64, allowSpecial bool) int {
+	if allowSpecial && g.totalColumns == 8 {
+		const localXDivisor = float32(512.0 / 7)
+		return clampInt(int(math.Floor(float64(float32(x)/localXDivisor))), 0, 6) + 1
+	}
+	localXDivisor := 512 / float32(g.totalColumns)
+	return clampInt(int(math.Floor(float64(float32(x)/localXDivisor))), 0, g.totalColumns-1)
+}
+
+func (g *patternGenerator) hasSound(flag HitSoundFlags) bool {
+	return g.object.HitSound()&flag != 0
+}
+
+// ---------- lazer HitObjectPatternGenerator ----------
+
+type circlePatternGenerator struct {
+	patternGenerator
+	convertType patternType
+	stairType   patternType
+}
+
+func newCirclePatternGenerator(
+	g patternGenerator,
+	controlPoints *ControlPoints,
+	previousTime float64,
+	previousPosition [2]float64,
+	density float64,
+	lastStair patternType,
+) *circlePatternGenerator {
+	c := &circlePatternGenerator{patternGenerator: g, stairType: lastStair}
+	start := float64(g.object.StartTime())
+	beatLength := controlPoints.BeatLengthAt(start)
+	pos := g.object.Pos()
+	positionSeparation := math.Hypot(float64(pos.X)-previousPosition[0], float64(pos.Y)-previousPosition[1])
+	timeSeparation := start - previousTime
+
+	switch {
+	case timeSeparation <= 80:
+		// more than 187 BPM
+		c.convertType |= patternForceNotStack | patternKeepSingle
+	case timeSeparation <= 95:
+		// more than 157 BPM
+		c.convertType |= patternForceNotStack | patternKeepSingle | lastStair
+	case timeSeparation <= 105:
+		// more than 140 BPM
+		c.convertType |= patternForceNotStack | patternLowProbability
+	case timeSeparation <= 125:
+		// more than 120 BPM
+		c.convertType |= patternForceNotStack
+	case timeSeparation <= 135 && positionSeparation < 20:
+		// more than 111 BPM stream
+		c.convertType |= patternCycle | patternKeepSingle
+	case timeSeparation <= 150 && positionSeparation < 20:
+		// more than 100 BPM stream
+		c.convertType |= patternForceStack | patternLowProbability
+	case positionSeparation < 20 && density >= beatLength/2.5:
+		// low density stream
+		c.convertType |= patternReverse | patternLowProbability
+	case density < beatLength/2.5 || controlPoints.KiaiAt(start):
+		// high density
+	default:
+		c.convertType |= patternLowProbability
+	}
+
+	if !c.convertType.has(patternKeepSingle) {
+		if c.hasSound(HitSoundFinish) && c.totalColumns != 8 {
+			c.convertType |= patternMirror
+		} else if c.hasSound(HitSoundClap) {
+			c.convertType |= patternGathered
+		}
+	}
+	return c
+}
+
+func (c *circlePatternGenerator) generate() maniaPattern {
+	var pattern maniaPattern
+	defer func() {
+		// like lazer, only the patterns built here move the stair, not the random ones
+		for _, note := range pattern.notes {
+			if c.convertType.has(patternStair) && note.Column == c.totalColumns-1 {
+				c.stairType = patternReverseStair
+			}
+			if c.convertType.has(patternReverseStair) && note.Column == c.randomStart {
+				c.stairType = patternStair
+			}
+		}
+	}()
+
+	if c.totalColumns == 1 {
+		c.addNote(&pattern, 0)
+		return pattern
+	}
+
+	lastColumn := 0
+	if len(c.previous.notes) > 0 {
+		lastColumn = c.previous.notes[0].Column
+	}
+
+	if c.convertType.has(patternReverse) && len(c.previous.notes) > 0 {
+		// the columns of the last pattern, mirrored
+		for i := c.randomStart; i < c.totalColumns; i++ {
+			if c.previous.hasColumn(i) {
+				c.addNote(&pattern, c.randomStart+c.totalColumns-i-1)
+			}
+		}
+		return pattern
+	}
+
+	if c.convertType.has(patternCycle) && len(c.previous.notes) == 1 &&
+		// don't overload the special key of 7K+1
+		(c.totalColumns != 8 || lastColumn != 0) &&
+		// nor cycle from the centre column
+		(c.totalColumns%2 == 0 || lastColumn != c.totalColumns/2) {
+		c.addNote(&pattern, c.randomStart+c.totalColumns-lastColumn-1)
+		return pattern
+	}
+
+	if c.convertType.has(patternForceStack) && len(c.previous.notes) > 0 {
+		for i := c.randomStart; i < c.totalColumns; i++ {
+			if c.previous.hasColumn(i) {
+				c.addNote(&pattern, i)
+			}
+		}
+		return pattern
+	}
+
+	if len(c.previous.notes) == 1 {
+		if c.convertType.has(patternStair) {
+			target := lastColumn + 1
+			if target == c.totalColumns {
+				target = c.randomStart
+			}
+			c.addNote(&pattern, target)
+			return pattern
+		}
+		if c.convertType.has(patternReverseStair) {
+			target := lastColumn - 1
+			if target == c.randomStart-1 {
+				target = c.totalColumns - 1
+			}
+			c.addNote(&pattern, target)
+			return pattern
+		}
+	}
+
+	if c.convertType.has(patternKeepSingle) {
+		return c.randomNotes(1)
+	}
+
+	lowProbability := c.convertType.has(patternLowProbability)
+	switch {
+	case c.convertType.has(patternMirror) && c.conversionDifficulty > 6.5:
+		return c.randomPatternWithMirrored(0.12, 0.38, 0.12)
+	case c.convertType.has(patternMirror) && c.conversionDifficulty > 4:
+		return c.randomPatternWithMirrored(0.12, 0.17, 0)
+	case c.convertType.has(patternMirror):
+		return c.randomPatternWithMirrored(0.12, 0, 0)
+	case c.conversionDifficulty > 6.5 && lowProbability:
+		return c.randomPattern(0.78, 0.42, 0, 0)
+	case c.conversionDifficulty > 6.5:
+		return c.randomPattern(1, 0.62, 0, 0)
+	case c.conversionDifficulty > 4 && lowProbability:
+		return c.randomPattern(0.35, 0.08, 0, 0)
+	case c.conversionDifficulty > 4:
+		return c.randomPattern(0.52, 0.15, 0, 0)
+	case c.conversionDifficulty > 2 && lowProbability:
+		return c.randomPattern(0.18, 0, 0, 0)
+	case c.conversionDifficulty > 2:
+		return c.randomPattern(0.45, 0, 0, 0)
+	}
+	return c.randomPattern(0, 0, 0, 0)
+}
+
+func (c *circlePatternGenerator) randomNotes(noteCount int) maniaPattern {
+	var pattern maniaPattern
+	allowStacking := !c.convertType.has(patternForceNotStack)
+	if !allowStacking {
+		noteCount = min(noteCount, c.totalColumns-c.randomStart-c.previous.columnsWithNotes())
+	}
+	next := func(last int) int {
+		if !c.convertType.has(patternGathered) {
+			return c.randomColumn()
+		}
+		last++
+		if last == c.totalColumns {
+			last = c.randomStart
+		}
+		return last
+	}
+
+	column := c.column(float64(c.object.Pos().X), true)
+	for range noteCount {
+		if allowStacking {
+			column = c.findAvailableColumn(column, c.randomStart, c.totalColumns, next, nil, &pattern)
+		} else {
+			column = c.findAvailableColumn(column, c.randomStart, c.totalColumns, next, nil, &pattern, &c.previous)
+		}
+		c.addNote(&pattern, column)
+	}
+	return pattern
+}
+
+func (c *circlePatternGenerator) hasSpecialColumn() bool {
+	return c.hasSound(HitSoundClap) && c.hasSound(HitSoundFinish)
+}
+
+func (c *circlePatternGenerator) randomPattern(p2, p3, p4, p5 float64) maniaPattern {
+	pattern := c.randomNotes(c.circleNoteCount(p2, p3, p4, p5))
+	if c.randomStart > 0 && c.hasSpecialColumn() {
+		c.addNote(&pattern, 0)
+	}
+	return pattern
+}
+
+func (c *circlePatternGenerator) randomPatternWithMirrored(centreProbability, p2, p3 float64) maniaPattern {
+	if c.convertType.has(patternForceNotStack) {
+		return c.randomPattern(0.5+p2/2, p2, (p2+p3)/2, p3)
+	}
+
+	var pattern maniaPattern
+	noteCount, addToCentre := c.mirroredNoteCount(centreProbability, p2, p3)
+
+	columnLimit := c.totalColumns / 2 // the odd centre column is left out
+	column := c.random.nextRange(c.randomStart, columnLimit)
+	for range noteCount {
+		column = c.findAvailableColumn(column, c.randomStart, columnLimit, nil, nil, &pattern)
+		c.addNote(&pattern, column)
+		c.addNote(&pattern, c.randomStart+c.totalColumns-column-1)
+	}
+	if addToCentre {
+		c.addNote(&pattern, c.totalColumns/2)
+	}
+	if c.randomStart > 0 && c.hasSpecialColumn() {
+		c.addNote(&pattern, 0)
+	}
+	return pattern
+}
+
+func (c *circlePatternGenerator) circleNoteCount(p2, p3, p4, p5 float64) int {
+	switch c.totalColumns {
+	case 2:
+		p2, p3, p4, p5 = 0, 0, 0, 0
+	case 3:
+		p2, p3, p4, p5 = min(p2, 0.1), 0, 0, 0
+	case 4:
+		p2, p3, p4, p5 = min(p2, 0.23), min(p3, 0.04), 0, 0
+	case 5:
+		p3, p4, p5 = min(p3, 0.15), min(p4, 0.03), 0
+	}
+	if c.hasSound(HitSoundClap) {
+		p2 = 1
+	}
+	return c.randomNoteCount(p2, p3, p4, p5)
+}
+
+func (c *circlePatternGenerator) mirroredNoteCount(centreProbability, p2, p3 float64) (noteCount int, addToCentre bool) {
+	// stable compares against inverse probabilities and doubles them, which is undone here
+	switch c.totalColumns {
+	case 2:
+		centreProbability, p2, p3 = 0, 0, 0
+	case 3:
+		centreProbability, p2, p3 = min(centreProbability, 0.03), 0, 0
+	case 4:
+		centreProbability, p2, p3 = 0, 1-max((1-p2)*2, 0.8), 0
+	case 5:
+		centreProbability, p3 = min(centreProbability, 0.03), 0
+	case 6:
+		centreProbability, p2, p3 = 0, 1-max((1-p2)*2, 0.5), 1-max((1-p3)*2, 0.85)
+	}
+	p2 = clampFloat(p2, 0, 1)
+	p3 = clampFloat(p3, 0, 1)
+
+	centreVal := c.random.nextDouble()
+	noteCount = c.randomNoteCount(p2, p3)
+	addToCentre = c.totalColumns%2 != 0 && noteCount != 3 && centreVal > 1-centreProbability
+	return noteCount, addToCentre
+}
+
+func (c *circlePatternGenerator) addNote(pattern *maniaPattern, column int) {
+	start := float64(c.object.StartTime())
+	pattern.add(ManiaNote{Column: column, Time: start, EndTime: start})
+}
+
+// ---------- lazer PathObjectPatternGenerator ----------
+
+type sliderPatternGenerator struct {
+	patternGenerator
+	convertType     patternType
+	slider          Slider
+	startTime       int
+	endTime         int
+	segmentDuration int
+	spanCount       int
+}
+
+func newSliderPatternGenerator(
+	g patternGenerator,
+	b *Beatmap,
+	controlPoints *ControlPoints,
+	s Slider,
+) *sliderPatternGenerator {
+	gen := &sliderPatternGenerator{patternGenerator: g, slider: s}
+	start := float64(s.Time)
+	if !controlPoints.KiaiAt(start) {
+		gen.convertType = patternLowProbability
+	}
+	beatLength := controlPoints.BeatLengthAt(start) / controlPoints.SliderVelocityAt(start)
+
+	gen.spanCount = max(1, s.Slides)
+	gen.startTime = int(math.RoundToEven(start))
+	// the end as stable computes it, which can be off from the slider's own end
+	gen.endTime = int(math.Floor(float64(gen.startTime) + s.Length*beatLength*float64(gen.spanCount)*0.01/b.Difficulty.SliderMultiplier))
+	gen.segmentDuration = (gen.endTime - gen.startTime) / gen.spanCount
+	return gen
+}
+
+// generate returns the notes that end before the slider, then those that end with it. The last
+// pattern is what the next object builds on.
+func (gen *sliderPatternGenerator) generate() []maniaPattern {
+	original := gen.generateAll()
+	if len(original.notes) == 1 {
+		return []maniaPattern{original}
+	}
+	var intermediate, atEnd maniaPattern
+	for _, note := range original.notes {
+		if int(math.RoundToEven(note.EndTime)) != gen.endTime {
+			intermediate.add(note)
+		} else {
+			atEnd.add(note)
+		}
+	}
+	return []maniaPattern{intermediate, atEnd}
+}
+
+func (gen *sliderPatternGenerator) generateAll() maniaPattern {
+	if gen.totalColumns == 1 {
+		var pattern maniaPattern
+		gen.addNote(&pattern, 0, gen.startTime, gen.endTime)
+		return pattern
+	}
+
+	if gen.spanCount > 1 {
+		switch {
+		case gen.segmentDuration <= 90:
+			return gen.randomHoldNotes(gen.startTime, 1)
+		case gen.segmentDuration <= 120:
+			gen.convertType |= patternForceNotStack
+			return gen.randomNotes(gen.startTime, gen.spanCount+1)
+		case gen.segmentDuration <= 160:
+			return gen.stair(gen.startTime)
+		case gen.segmentDuration <= 200 && gen.conversionDifficulty > 3:
+			return gen.randomMultipleNotes(gen.startTime)
+		case gen.endTime-gen.startTime >= 4000:
+			return gen.nRandomNotes(gen.startTime, 0.23, 0, 0)
+		case gen.segmentDuration > 400 && gen.spanCount < gen.totalColumns-1-gen.randomStart:
+			return gen.tiledHoldNotes(gen.startTime)
+		}
+		return gen.holdAndNormalNotes(gen.startTime)
+	}
+
+	if gen.segmentDuration <= 110 {
+		if gen.previous.columnsWithNotes() < gen.totalColumns {
+			gen.convertType |= patternForceNotStack
+		} else {
+			gen.convertType &^= patternForceNotStack
+		}
+		return gen.randomNotes(gen.startTime, pick(gen.segmentDuration < 80, 1, 2))
+	}
+
+	lowProbability := gen.convertType.has(patternLowProbability)
+	switch {
+	case gen.conversionDifficulty > 6.5 && lowProbability:
+		return gen.nRandomNotes(gen.startTime, 0.78, 0.3, 0)
+	case gen.conversionDifficulty > 6.5:
+		return gen.nRandomNotes(gen.startTime, 0.85, 0.36, 0.03)
+	case gen.conversionDifficulty > 4 && lowProbability:
+		return gen.nRandomNotes(gen.startTime, 0.43, 0.08, 0)
+	case gen.conversionDifficulty > 4:
+		return gen.nRandomNotes(gen.startTime, 0.56, 0.18, 0)
+	case gen.conversionDifficulty > 2.5 && lowProbability:
+		return gen.nRandomNotes(gen.startTime, 0.3, 0, 0)
+	case gen.conversionDifficulty > 2.5:
+		return gen.nRandomNotes(gen.startTime, 0.37, 0.08, 0)
+	case lowProbability:
+		return gen.nRandomNotes(gen.startTime, 0.17, 0, 0)
+	}
+	return gen.nRandomNotes(gen.startTime, 0.27, 0, 0)
+}
+
+// randomHoldNotes: noteCount holds over the whole slider, avoiding the last pattern while it can.
+func (gen *sliderPatternGenerator) randomHoldNotes(startTime, noteCount int) maniaPattern {
+	var pattern maniaPattern
+	usableColumns := gen.totalColumns - gen.randomStart - gen.previous.columnsWithNotes()
+	column := gen.randomColumn()
+	for range min(usableColumns, noteCount) {
+		column = gen.availableColumn(column, &pattern, &gen.previous)
+		gen.addNote(&pattern, column, startTime, gen.endTime)
+	}
+	// apart from the loop above to draw from the generator in the same order as lazer
+	for range noteCount - usableColumns {
+		column = gen.availableColumn(column, &pattern)
+		gen.addNote(&pattern, column, startTime, gen.endTime)
+	}
+	return pattern
+}
+
+// randomNotes: one note on every node, never twice in a row in the same column.
+func (gen *sliderPatternGenerator) randomNotes(startTime, noteCount int) maniaPattern {
+	var pattern maniaPattern
+	column := gen.column(float64(gen.slider.PosXY.X), true)
+	if gen.convertType.has(patternForceNotStack) && gen.previous.columnsWithNotes() < gen.totalColumns {
+		column = gen.availableColumn(column, &gen.previous)
+	}
+	lastColumn := column
+	for range noteCount {
+		gen.addNote(&pattern, column, startTime, startTime)
+		column = gen.findAvailableColumn(column, gen.randomStart, gen.totalColumns, nil, func(c int) bool { return c != lastColumn })
+		lastColumn = column
+		startTime += gen.segmentDuration
+	}
+	return pattern
+}
+
+// stair: one note on every node, walking across the columns and back.
+func (gen *sliderPatternGenerator) stair(startTime int) maniaPattern {
+	var pattern maniaPattern
+	column := gen.column(float64(gen.slider.PosXY.X), true)
+	increasing := gen.random.nextDouble() > 0.5
+	for range gen.spanCount + 1 {
+		gen.addNote(&pattern, column, startTime, startTime)
+		startTime += gen.segmentDuration
+
+		switch {
+		case increasing && column >= gen.totalColumns-1:
+			increasing = false
+			column--
+		case increasing:
+			column++
+		case column <= gen.randomStart:
+			increasing = true
+			column++
+		default:
+			column--
+		}
+	}
+	return pattern
+}
+
+// randomMultipleNotes: two notes a fixed interval apart on every node.
+func (gen *sliderPatternGenerator) randomMultipleNotes(startTime int) maniaPattern {
+	var pattern maniaPattern
+	legacy := gen.totalColumns >= 4 && gen.totalColumns <= 8
+	interval := gen.random.nextRange(1, gen.totalColumns-pick(legacy, 1, 0))
+
+	column := gen.column(float64(gen.slider.PosXY.X), true)
+	for range gen.spanCount + 1 {
+		gen.addNote(&pattern, column, startTime, startTime)
+
+		column += interval
+		if column >= gen.totalColumns-gen.randomStart {
+			column = column - gen.totalColumns - gen.randomStart + pick(legacy, 1, 0)
+		}
+		column += gen.randomStart
+
+		// no long runs of doubles in 2K
+		if gen.totalColumns > 2 {
+			gen.addNote(&pattern, column, startTime, startTime)
+		}
+
+		column = gen.randomColumn()
+		startTime += gen.segmentDuration
+	}
+	return pattern
+}
+
+// nRandomNotes: holds over the whole slider, as many as a roll of p2, p3 and p4 gives.
+func (gen *sliderPatternGenerator) nRandomNotes(startTime int, p2, p3, p4 float64) maniaPattern {
+	switch gen.totalColumns {
+	case 2:
+		p2, p3, p4 = 0, 0, 0
+	case 3:
+		p2, p3, p4 = min(p2, 0.1), 0, 0
+	case 4:
+		p2, p3, p4 = min(p2, 0.3), min(p3, 0.04), 0
+	case 5:
+		p2, p3, p4 = min(p2, 0.34), min(p3, 0.1), min(p4, 0.03)
+	}
+
+	const doubleSounds = HitSoundClap | HitSoundFinish
+	if !gen.convertType.has(patternLowProbability) &&
+		(gen.hasSound(doubleSounds) || gen.soundAt(gen.startTime)&doubleSounds != 0) {
+		p2 = 1
+	}
+	return gen.randomHoldNotes(startTime, gen.randomNoteCount(p2, p3, p4))
+}
+
+// tiledHoldNotes: a stair of holds that all end with the slider.
+func (gen *sliderPatternGenerator) tiledHoldNotes(startTime int) maniaPattern {
+	var pattern maniaPattern
+	columnRepeat := min(gen.spanCount, gen.totalColumns)
+	// by integer rounding this can be before gen.endTime
+	endTime := startTime + gen.segmentDuration*gen.spanCount
+
+	column := gen.column(float64(gen.slider.PosXY.X), true)
+	if gen.convertType.has(patternForceNotStack) && gen.previous.columnsWithNotes() < gen.totalColumns {
+		column = gen.availableColumn(column, &gen.previous)
+	}
+	for range columnRepeat {
+		column = gen.availableColumn(column, &pattern)
+		gen.addNote(&pattern, column, startTime, endTime)
+		startTime += gen.segmentDuration
+	}
+	return pattern
+}
+
+// holdAndNormalNotes: a hold over the slider with notes beside it on its nodes.
+func (gen *sliderPatternGenerator) holdAndNormalNotes(startTime int) maniaPattern {
+	var pattern maniaPattern
+	holdColumn := gen.column(float64(gen.slider.PosXY.X), true)
+	if gen.convertType.has(patternForceNotStack) && gen.previous.columnsWithNotes() < gen.totalColumns {
+		holdColumn = gen.availableColumn(holdColumn, &gen.previous)
+	}
+	gen.addNote(&pattern, holdColumn, startTime, gen.endTime)
+
+	column := gen.randomColumn()
+	var noteCount int
+	switch {
+	case gen.conversionDifficulty > 6.5:
+		noteCount = gen.randomNoteCount(0.63, 0)
+	case gen.conversionDifficulty > 4:
+		noteCount = gen.randomNoteCount(pick(gen.totalColumns < 6, 0.12, 0.45), 0)
+	case gen.conversionDifficulty > 2.5:
+		noteCount = gen.randomNoteCount(pick(gen.totalColumns < 6, 0, 0.24), 0)
+	}
+	noteCount = min(gen.totalColumns-1, noteCount)
+
+	ignoreHead := gen.soundAt(startTime)&(HitSoundWhistle|HitSoundFinish|HitSoundClap) == 0
+	notHold := func(c int) bool { return c != holdColumn }
+	for range gen.spanCount + 1 {
+		var row maniaPattern
+		if !ignoreHead || startTime != gen.startTime {
+			for range noteCount {
+				column = gen.findAvailableColumn(column, gen.randomStart, gen.totalColumns, nil, notHold, &row)
+				gen.addNote(&row, column, startTime, startTime)
+			}
+		}
+		pattern.addPattern(row)
+		startTime += gen.segmentDuration
+	}
+	return pattern
+}
+
+// soundAt is the sound of the slider node at or after time.
+func (gen *sliderPatternGenerator) soundAt(time int) HitSoundFlags {
+	index := 0
+	if gen.segmentDuration != 0 {
+		index = (time - gen.startTime) / gen.segmentDuration
+	}
+	sounds := nodeSounds(gen.slider)
+	if index < 0 || index >= len(sounds) {
+		return gen.slider.Sound
+	}
+	return sounds[index]
+}
+
+func (gen *sliderPatternGenerator) addNote(pattern *maniaPattern, column, startTime, endTime int) {
+	pattern.add(ManiaNote{Column: column, Time: float64(startTime), EndTime: float64(endTime)})
+}
+
+// ---------- lazer EndTimeObjectPatternGenerator ----------
+
+// spinnerPattern converts a spinner to a note, or to a hold when it lasts 100ms or more.
+func spinnerPattern(g patternGenerator, s Spinner) maniaPattern {
+	start, end := float64(s.Time), float64(s.EndTime)
+	note := ManiaNote{Time: start, EndTime: start}
+	if end-start >= 100 {
+		note.EndTime = end
+	}
+	switch {
+	case g.totalColumns == 8 && g.hasSound(HitSoundFinish) && end-start < 1000:
+		note.Column = 0
+	case g.totalColumns == 8:
+		note.Column = g.availableColumn(g.randomColumn(), &g.previous)
+	default:
+		note.Column = g.random.nextRange(0, g.totalColumns)
+	}
+	var pattern maniaPattern
+	pattern.add(note)
+	return pattern
+}
+
+// ---------- conversion ----------
+
+// notes over which the density is averaged
+const maxNotesForDensity = 7
+
+// maniaSeed is the seed of a convert's random, from the difficulty settings as floats.
+func maniaSeed(d Difficulty) int32 {
+	hp, cs, od, ar := float32(d.HPDrainRate), float32(d.CircleSize), float32(d.OverallDifficulty), float32(d.ApproachRate)
+	return int32(math.RoundToEven(float64(hp+cs)))*20 + int32(float64(od)*41.2) + int32(math.RoundToEven(float64(ar)))
+}
+
+// maniaConversionDifficulty rates how dense a convert may get, lazer
+// LegacyPatternGenerator.ConversionDifficulty.
+func maniaConversionDifficulty(b *Beatmap) float64 {
+	drainTime := 0
+	if len(b.HitObjects) > 0 {
+		breakTime := 0.0
+		for _, br := range b.Breaks {
+			breakTime += br.End - br.Start
+		}
+		first, last := b.HitObjects[0].StartTime(), b.HitObjects[len(b.HitObjects)-1].StartTime()
+		drainTime = int((float64(last-first) - breakTime) / 1000)
+	}
+	if drainTime == 0 {
+		drainTime = 10000
+	}
+	d := b.Difficulty
+	settings := float64(float32(d.HPDrainRate) + float32(clampFloat(d.ApproachRate, 4, 7)))
+	difficulty := (settings/1.5 + float64(len(b.HitObjects))/float64(drainTime)*9) / 38 * 5 / 1.15
+	return min(difficulty, 12)
+}
+
+// convertToMania follows lazer's ManiaBeatmapConverter for osu!standard maps: every object is
+// turned into a pattern by its kind, its spacing to the last object, the note density, its
+// hitsounds and a random seeded by the difficulty settings, so the same map always converts
+// the same way.
+func convertToMania(b *Beatmap) (out ManiaBeatmap, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r != errNotEnoughColumns {
+				panic(r)
+			}
+			err = errNotEnoughColumns
+		}
+	}()
+
+	keyCount := ManiaKeyCount(b)
+	out = ManiaBeatmap{KeyCount: keyCount}
+	controlPoints := b.ControlPoints()
+	random := newLegacyRandom(maniaSeed(b.Difficulty))
+	conversionDifficulty := maniaConversionDifficulty(b)
+	randomStart := 0
+	if keyCount == 8 {
+		randomStart = 1
+	}
+
+	var (
+		lastPattern   maniaPattern
+		lastTime      float64
+		lastPosition  [2]float64
+		lastStair     = patternStair
+		prevNoteTimes []float64
+		density       = float64(math.MaxInt32)
+	)
+	recordNote := func(time float64, x, y float64) {
+		lastTime = time
+		lastPosition = [2]float64{x, y}
+	}
+	computeDensity := func(time float64) {
+		prevNoteTimes = append(prevNoteTimes, time)
+		if len(prevNoteTimes) > maxNotesForDensity {
+			prevNoteTimes = prevNoteTimes[1:]
+		}
+		if len(prevNoteTimes) >= 2 {
+			density = (prevNoteTimes[len(prevNoteTimes)-1] - prevNoteTimes[0]) / float64(len(prevNoteTimes))
+		}
+	}
+
+	for _, ho := range b.HitObjects {
+		g := patternGenerator{
+			random:               random,
+			object:               ho,
+			previous:             lastPattern,
+			totalColumns:         keyCount,
+			randomStart:          randomStart,
+			conversionDifficulty: conversionDifficulty,
+		}
+		switch o := ho.(type) {
+		case Slider:
+			gen := newSliderPatternGenerator(g, b, controlPoints, o)
+			for i := range gen.spanCount + 1 {
+				time := float64(o.Time) + float64(gen.segmentDuration*i)
+				recordNote(time, float64(o.PosXY.X), float64(o.PosXY.Y))
+				computeDensity(time)
+			}
+			for _, pattern := range gen.generate() {
+				lastPattern = pattern
+				out.Notes = append(out.Notes, pattern.notes...)
+			}
+		case Spinner:
+			recordNote(float64(o.EndTime), 256, 192)
+			computeDensity(float64(o.EndTime))
+			// spinners leave the last pattern as it is
+			out.Notes = append(out.Notes, spinnerPattern(g, o).notes...)
+		case Hold:
+			// not in osu!standard maps
+		default:
+			start := float64(ho.StartTime())
+			computeDensity(start)
+			gen := newCirclePatternGenerator(g, controlPoints, lastTime, lastPosition, density, lastStair)
+			recordNote(start, float64(ho.Pos().X), float64(ho.Pos().Y))
+			lastPattern = gen.generate()
+			lastStair = gen.stairType
+			out.Notes = append(out.Notes, lastPattern.notes...)
+		}
+	}
+	sort.SliceStable(out.Notes, func(i, j int) bool { return out.Notes[i].Time < out.Notes[j].Time })
+	return out, nil
+}
+
+func pick[T any](cond bool, ifTrue, ifFalse T) T {
+	if cond {
+		return ifTrue
+	}
+	return ifFalse
+}
@@ -0,0 +1,241 @@
+package dotosu
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+type AssetKind uint8
+
+const (
+	AssetOther AssetKind = iota
+	AssetAudio
+	AssetBackground
+	AssetVideo
+	AssetHitsound
+	AssetStoryboard // images and samples used by a storyboard
+	AssetImage      // unreferenced images, usually skin elements
+)
+
+func (k AssetKind) String() string {
+	switch k {
+	case AssetAudio:
+		return "audio"
+	case AssetBackground:
+		return "background"
+	case AssetVideo:
+		return "video"
+	case AssetHitsound:
+		return "hitsound"
+	case AssetStoryboard:
+		return "storyboard"
+	case AssetImage:
+		return "image"
+	}
+	return "other"
+}
+
+// Asset is one non-beatmap file in an archive. Path uses forward slashes and keeps subfolders.
+type Asset struct {
+	Path       string
+	Kind       AssetKind
+	Size       int64
+	Referenced bool // some difficulty or the .osb points at it
+}
+
+// OszDifficulty is one .osu file of an archive; Err is set when it failed to decode.
+type OszDifficulty struct {
+	Path    string
+	Beatmap *Beatmap
+	Err     error
+}
+
+type Osz struct {
+	Difficulties   []OszDifficulty
+	Storyboard     *Storyboard // nil when the set has no .osb
+	StoryboardPath string
+	Assets         []Asset
+
+	files map[string]*zip.File // by lowercased path
+}
+
+// OpenOsz reads a beatmapset archive. Difficulties that fail to decode are kept with their
+// error instead of failing the whole set; only an unreadable archive or .osb is an error.
+func OpenOsz(r io.ReaderAt, size int64) (*Osz, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	o := &Osz{files: make(map[string]*zip.File, len(zr.File))}
+	var assets []*zip.File
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		p := oszPath(f.Name)
+		o.files[strings.ToLower(p)] = f
+		switch strings.ToLower(path.Ext(p)) {
+		case ".osu":
+			data, err := readZipFile(f)
+			if err != nil {
+				o.Difficulties = append(o.Difficulties, OszDifficulty{Path: p, Err: err})
+				continue
+			}
+			b, err := Decode(bytes.NewReader(data))
+			o.Difficulties = append(o.Difficulties, OszDifficulty{Path: p, Beatmap: b, Err: err})
+		case ".osb":
+			data, err := readZipFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", p, err)
+			}
+			sb, err := DecodeStoryboard(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("decoding %s: %w", p, err)
+			}
+			o.Storyboard = sb
+			o.StoryboardPath = p
+		default:
+			assets = append(assets, f)
+		}
+	}
+	sort.Slice(o.Difficulties, func(i, j int) bool { return o.Difficulties[i].Path < o.Difficulties[j].Path })
+
+	refs := o.references()
+	for _, f := range assets {
+		p := oszPath(f.Name)
+		kind, referenced := refs[strings.ToLower(p)]
+		if !referenced {
+			kind = kindByExtension(p)
+		}
+		o.Assets = append(o.Assets, Asset{
+			Path:       p,
+			Kind:       kind,
+			Size:       int64(f.UncompressedSize64),
+			Referenced: referenced,
+		})
+	}
+	sort.Slice(o.Assets, func(i, j int) bool { return o.Assets[i].Path < o.Assets[j].Path })
+	return o, nil
+}
+
+// Beatmaps returns the difficulties that decoded successfully.
+func (o *Osz) Beatmaps() []*Beatmap {
+	out := make([]*Beatmap, 0, len(o.Difficulties))
+	for _, d := range o.Difficulties {
+		if d.Err == nil {
+			out = append(out, d.Beatmap)
+		}
+	}
+	return out
+}
+
+// Open opens any file of the archive. Lookups ignore case and slash direction, like stable does.
+func (o *Osz) Open(name string) (io.ReadCloser, error) {
+	f, ok := o.files[strings.ToLower(oszPath(name))]
+	if !ok {
+		return nil, fmt.Errorf("%s: not in archive", name)
+	}
+	return f.Open()
+}
+
+func (o *Osz) ReadFile(name string) ([]byte, error) {
+	f, ok := o.files[strings.ToLower(oszPath(name))]
+	if !ok {
+		return nil, fmt.Errorf("%s: not in archive", name)
+	}
+	return readZipFile(f)
+}
+
+// ImageSize reads the size of a png or jpg of the archive, for Storyboard.PlayfieldCover.
+func (o *Osz) ImageSize(name string) (width, height float64, ok bool) {
+	f, err := o.Open(name)
+	if err != nil {
+		return 0, 0, false
+	}
+	defer f.Close()
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, false
+	}
+	return float64(config.Width), float64(config.Height), true
+}
+
+// references collects every file named by the difficulties and the .osb, keyed by lowercased path.
+func (o *Osz) references() map[string]AssetKind {
+	refs := map[string]AssetKind{}
+	add := func(p string, kind AssetKind) {
+		if p == "" {
+			return
+		}
+		key := strings.ToLower(oszPath(p))
+		// a background reused as a storyboard sprite is still the background
+		if _, ok := refs[key]; !ok || kind < AssetStoryboard {
+			refs[key] = kind
+		}
+	}
+	addStoryboard := func(sb *Storyboard) {
+		for _, e := range sb.Elements {
+			switch el := e.(type) {
+			case *Sprite:
+				add(el.Path, AssetStoryboard)
+			case *Animation:
+				for _, p := range el.FramePaths() {
+					add(p, AssetStoryboard)
+				}
+			case *StoryboardSample:
+				add(el.Path, AssetStoryboard)
+			}
+		}
+	}
+	for _, d := range o.Difficulties {
+		if d.Err != nil {
+			continue
+		}
+		b := d.Beatmap
+		add(b.General.AudioFilename, AssetAudio)
+		add(b.Metadata.BackgroundFile, AssetBackground)
+		add(b.Metadata.VideoFile, AssetVideo)
+		for _, ho := range b.HitObjects {
+			add(ho.Sample().Filename, AssetHitsound)
+		}
+		addStoryboard(&b.Storyboard)
+	}
+	if o.Storyboard != nil {
+		addStoryboard(o.Storyboard)
+	}
+	return refs
+}
+
+func kindByExtension(p string) AssetKind {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".wav", ".ogg", ".mp3":
+		// loose audio next to a beatmap is almost always a custom hitsound sample
+		return AssetHitsound
+	case ".png", ".jpg", ".jpeg":
+		return AssetImage
+	case ".mp4", ".avi", ".flv", ".m4v", ".mkv", ".webm":
+		return AssetVideo
+	}
+	return AssetOther
+}
+
+func oszPath(name string) string {
+	return strings.TrimPrefix(path.Clean(standardisePath(name)), "/")
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,76 @@
+package dotosu
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func TestOpenOsz(t *testing.T) {
+	const diff = `osu file format v14
+
+[General]
+AudioFilename: audio.mp3
+
+[Events]
+0,0,"bg.jpg",0,0
+Animation,Foreground,Centre,"sb\anim.png",320,240,2,100,LoopForever
+
+[HitObjects]
+256,192,1000,1,0,0:0:0:0:hits/clap.wav
+`
+	files := map[string]string{
+		"a - b (c) [Easy].osu": diff,
+		"a - b (c).osb":        "[Events]\nSprite,Overlay,Centre,\"bg.jpg\",320,240\n",
+		"audio.mp3":            "",
+		"BG.jpg":               "",
+		"sb/anim0.png":         "",
+		"sb/anim1.png":         "",
+		"hits\\clap.wav":       "",
+		"soft-hitnormal.wav":   "",
+		"hitcircle.png":        "",
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	osz, err := OpenOsz(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(osz.Beatmaps()) != 1 || osz.Storyboard == nil || len(osz.Storyboard.Elements) != 1 {
+		t.Fatalf("difficulties %+v, storyboard %v", osz.Difficulties, osz.Storyboard)
+	}
+
+	want := map[string]Asset{
+		"BG.jpg":             {Kind: AssetBackground, Referenced: true},
+		"audio.mp3":          {Kind: AssetAudio, Referenced: true},
+		"hitcircle.png":      {Kind: AssetImage},
+		"hits/clap.wav":      {Kind: AssetHitsound, Referenced: true},
+		"sb/anim0.png":       {Kind: AssetStoryboard, Referenced: true},
+		"sb/anim1.png":       {Kind: AssetStoryboard, Referenced: true},
+		"soft-hitnormal.wav": {Kind: AssetHitsound},
+	}
+	if len(osz.Assets) != len(want) {
+		t.Fatalf("got %d assets, want %d: %+v", len(osz.Assets), len(want), osz.Assets)
+	}
+	for _, a := range osz.Assets {
+		w, ok := want[a.Path]
+		if !ok || w.Kind != a.Kind || w.Referenced != a.Referenced {
+			t.Errorf("asset %+v, want %+v", a, w)
+		}
+	}
+
+	if _, err := osz.ReadFile("HITS\\Clap.wav"); err != nil {
+		t.Error(err)
+	}
+}
@@ -27,8 +27,10 @@ const (
 	secEditor
 	secMetadata
 	secDifficulty
+	secVariables
 	secEvents
 	secTimingPoints
+	secColours
 	secHitObjects
 )
 
@@ -40,11 +42,14 @@ type Beatmap struct {
 	Editor        Editor
 	Metadata      Metadata
 	Difficulty    Difficulty
+	Colours       Colours
 
 	Breaks          []BreakPeriod
 	TimingPoints    []TimingPoint
 	HitObjects      []HitObject
+	Storyboard      Storyboard
 	UnhandledEvents []string
+	Warnings        []Diagnostic // problems skipped over by a lenient decode
 
 	Bookmarks    []int
 	BeatDivisor  int
@@ -86,6 +91,22 @@ type Difficulty struct {
 
 type BreakPeriod struct{ Start, End float64 }
 
+type Colour struct{ R, G, B uint8 }
+
+// DefaultComboColours are used when the beatmap has no [Colours] section (lazer default skin).
+var DefaultComboColours = []Colour{
+	{R: 255, G: 192, B: 0},
+	{R: 0, G: 202, B: 0},
+	{R: 18, G: 124, B: 255},
+	{R: 242, G: 24, B: 57},
+}
+
+type Colours struct {
+	Combo               []Colour // in order of appearance, like lazer
+	SliderTrackOverride *Colour
+	SliderBorder        *Colour
+}
+
 type TimingPoint struct {
 	Time                     int
 	BeatLength               float64
@@ -140,6 +161,8 @@ const (
 	TypeComboSkip2                                // 32
 	TypeComboSkip3                                // 64
 	TypeHold       HitObjectTypeFlags = 1 << 7    // 128
+
+	TypeComboSkip = TypeComboSkip1 | TypeComboSkip2 | TypeComboSkip3
 )
 
 type Vec2 struct{ X, Y int }
@@ -164,6 +187,7 @@ const (
 	PathLinear
 	PathCatmull
 	PathPerfect
+	PathBSpline // lazer "B<degree>", e.g. B3
 )
 
 type SliderSegment struct {
@@ -174,7 +198,8 @@ type SliderSegment struct {
 
 type SliderPath struct {
 	Type     SliderPathType
-	Segments []SliderSegment // For Bezier, split when a control point repeats (red anchor).
+	Degree   int             // PathBSpline only
+	Segments []SliderSegment // For Bezier and B-spline, split when a control point repeats (red anchor).
 }
 
 type HitObject interface {
@@ -185,14 +210,25 @@ type HitObject interface {
 	Pos() Vec2
 	HitSound() HitSoundFlags
 	Sample() HitSampleSpec
+	ComboSkip() int
+	Combo() Combo
+}
+
+// Combo is computed after decoding, following lazer's IHasComboInformation.
+type Combo struct {
+	Number           int  // number drawn on the object, starting at 1
+	Index            int  // count of combos so far, starting at 1
+	IndexWithOffsets int  // Index plus all TypeComboSkip* offsets, selects the colour
+	LastInCombo      bool // next object starts a new combo
 }
 
 type BaseHO struct {
-	PosXY    Vec2
-	Time     int
-	Type     HitObjectTypeFlags
-	Sound    HitSoundFlags
-	SampleHS HitSampleSpec
+	PosXY     Vec2
+	Time      int
+	Type      HitObjectTypeFlags
+	Sound     HitSoundFlags
+	SampleHS  HitSampleSpec
+	ComboInfo Combo
 }
 
 func (b BaseHO) StartTime() int            { return b.Time }
@@ -201,6 +237,8 @@ func (b BaseHO) Flags() HitObjectTypeFlags { return b.Type }
 func (b BaseHO) Pos() Vec2                 { return b.PosXY }
 func (b BaseHO) HitSound() HitSoundFlags   { return b.Sound }
 func (b BaseHO) Sample() HitSampleSpec     { return b.SampleHS }
+func (b BaseHO) ComboSkip() int            { return int(b.Type&TypeComboSkip) >> 4 }
+func (b BaseHO) Combo() Combo              { return b.ComboInfo }
 
 type Circle struct{ BaseHO }
 
@@ -234,15 +272,23 @@ func (Hold) Kind() ObjectKind { return KindHold }
 // ---------- Public API ----------
 
 func DecodeFile(path string) (*Beatmap, error) {
+	return DecodeFileWithOptions(path, DecodeOptions{})
+}
+
+func DecodeFileWithOptions(path string, opts DecodeOptions) (*Beatmap, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
-	return Decode(f)
+	return DecodeWithOptions(f, opts)
 }
 
 func Decode(r io.Reader) (*Beatmap, error) {
+	return DecodeWithOptions(r, DecodeOptions{})
+}
+
+func DecodeWithOptions(r io.Reader, opts DecodeOptions) (*Beatmap, error) {
 	sc := bufio.NewScanner(r)
 	const maxLine = 1024 * 1024
 	buf := make([]byte, 64*1024)
@@ -250,7 +296,9 @@ func Decode(r io.Reader) (*Beatmap, error) {
 
 	// header
 	var header string
+	lineNo := 0
 	for sc.Scan() {
+		lineNo++
 		line := strings.TrimSpace(sc.Text())
 		if line == "" {
 			continue
@@ -288,9 +336,13 @@ func Decode(r io.Reader) (*Beatmap, error) {
 
 	sec := secNone
 	seenAR := false
+	d := &lineDecoder{}
+	sbParser := newStoryboardParser(&b.Storyboard, d)
 
 	for sc.Scan() {
-		line := strings.TrimSpace(sc.Text())
+		lineNo++
+		raw := strings.TrimRight(sc.Text(), " \t\r")
+		line := strings.TrimSpace(raw)
 		if line == "" || strings.HasPrefix(line, "//") {
 			continue
 		}
@@ -304,10 +356,14 @@ func Decode(r io.Reader) (*Beatmap, error) {
 				sec = secMetadata
 			case "[difficulty]":
 				sec = secDifficulty
+			case "[variables]":
+				sec = secVariables
 			case "[events]":
 				sec = secEvents
 			case "[timingpoints]":
 				sec = secTimingPoints
+			case "[colours]":
+				sec = secColours
 			case "[hitobjects]":
 				sec = secHitObjects
 			default:
@@ -316,6 +372,7 @@ func Decode(r io.Reader) (*Beatmap, error) {
 			continue
 		}
 
+		d.sec, d.line, d.raw = sec, lineNo, line
 		switch sec {
 		case secGeneral:
 			k, v := splitKeyVal(line)
@@ -323,9 +380,9 @@ func Decode(r io.Reader) (*Beatmap, error) {
 			case "audiofilename":
 				b.General.AudioFilename = standardisePath(v)
 			case "audioleadin":
-				b.General.AudioLeadIn = parseInt(v, 0)
+				b.General.AudioLeadIn = d.int(v, 0)
 			case "previewtime":
-				t := parseInt(v, -1)
+				t := d.int(v, -1)
 				if t != -1 {
 					t += offset
 				}
@@ -333,11 +390,11 @@ func Decode(r io.Reader) (*Beatmap, error) {
 			case "sampleset":
 				b.General.SampleSet = strings.ToLower(v)
 			case "samplevolume":
-				b.General.SampleVolume = parseInt(v, 100)
+				b.General.SampleVolume = d.int(v, 100)
 			case "stackleniency":
-				b.General.StackLeniency = parseFloat(v, 0)
+				b.General.StackLeniency = d.float(v, 0)
 			case "mode":
-				b.General.Mode = parseInt(v, 0)
+				b.General.Mode = d.int(v, 0)
 			case "letterboxinbreaks":
 				b.General.LetterboxInBreaks = parseBoolInt(v)
 			case "specialstyle":
@@ -349,9 +406,9 @@ func Decode(r io.Reader) (*Beatmap, error) {
 			case "samplesmatchplaybackrate":
 				b.General.SamplesMatchPlaybackRate = parseBoolInt(v)
 			case "countdown":
-				b.General.Countdown = parseInt(v, 0)
+				b.General.Countdown = d.int(v, 0)
 			case "countdownoffset":
-				b.General.CountdownOffset = parseInt(v, 0)
+				b.General.CountdownOffset = d.int(v, 0)
 			}
 
 		case secEditor:
@@ -361,18 +418,18 @@ func Decode(r io.Reader) (*Beatmap, error) {
 				if strings.TrimSpace(v) != "" {
 					for _, p := range strings.Split(v, ",") {
 						if p = strings.TrimSpace(p); p != "" {
-							b.Bookmarks = append(b.Bookmarks, parseInt(p, 0))
+							b.Bookmarks = append(b.Bookmarks, d.int(p, 0))
 						}
 					}
 				}
 			case "distancespacing":
-				b.Editor.DistanceSpacing = parseFloat(v, 0)
+				b.Editor.DistanceSpacing = d.float(v, 0)
 			case "beatdivisor":
-				b.BeatDivisor = clampInt(parseInt(v, 4), 1, 16)
+				b.BeatDivisor = clampInt(d.int(v, 4), 1, 16)
 			case "gridsize":
-				b.GridSize = parseInt(v, 4)
+				b.GridSize = d.int(v, 4)
 			case "timelinezoom":
-				b.TimelineZoom = math.Max(0, parseFloat(v, 0))
+				b.TimelineZoom = math.Max(0, d.float(v, 0))
 			}
 
 		case secMetadata:
@@ -395,45 +452,59 @@ func Decode(r io.Reader) (*Beatmap, error) {
 			case "tags":
 				b.Metadata.Tags = v
 			case "beatmapid":
-				b.Metadata.BeatmapID = parseInt(v, 0)
+				b.Metadata.BeatmapID = d.int(v, 0)
 			case "beatmapsetid":
-				b.Metadata.BeatmapSetID = parseInt(v, 0)
+				b.Metadata.BeatmapSetID = d.int(v, 0)
 			}
 
 		case secDifficulty:
 			k, v := splitKeyVal(line)
 			switch strings.ToLower(k) {
 			case "hpdrainrate":
-				b.Difficulty.HPDrainRate = parseFloat(v, 0)
+				b.Difficulty.HPDrainRate = d.float(v, 0)
 			case "circlesize":
-				b.Difficulty.CircleSize = parseFloat(v, 0)
+				b.Difficulty.CircleSize = d.float(v, 0)
 			case "overalldifficulty":
-				b.Difficulty.OverallDifficulty = parseFloat(v, 0)
+				b.Difficulty.OverallDifficulty = d.float(v, 0)
 				if !seenAR {
 					b.Difficulty.ApproachRate = b.Difficulty.OverallDifficulty
 				}
 			case "approachrate":
-				b.Difficulty.ApproachRate = parseFloat(v, 0)
+				b.Difficulty.ApproachRate = d.float(v, 0)
 				seenAR = true
 			case "slidermultiplier":
-				b.Difficulty.SliderMultiplier = parseFloat(v, 1)
+				b.Difficulty.SliderMultiplier = d.float(v, 1)
 			case "slidertickrate":
-				b.Difficulty.SliderTickRate = parseFloat(v, 1)
+				b.Difficulty.SliderTickRate = d.float(v, 1)
 			}
 
+		case secVariables:
+			sbParser.parseVariable(line)
+
 		case secEvents:
+			raw = sbParser.substitute(raw)
+			line = strings.TrimSpace(raw)
 			parts := splitCSV(line)
 			if len(parts) == 0 {
 				continue
 			}
+			if raw[0] == ' ' || raw[0] == '_' {
+				// storyboard command of the previous element
+				if !sbParser.parseLine(raw) {
+					b.UnhandledEvents = append(b.UnhandledEvents, line)
+				}
+				continue
+			}
 			switch strings.ToLower(parts[0]) {
 			case "0", "background":
+				sbParser.reset()
 				if len(parts) >= 3 {
 					b.Metadata.BackgroundFile = cleanFilename(parts[2])
 				} else {
 					b.UnhandledEvents = append(b.UnhandledEvents, line)
 				}
 			case "1", "video":
+				sbParser.reset()
 				if len(parts) >= 3 {
 					fn := cleanFilename(parts[2])
 					ext := strings.ToLower(filepath.Ext(fn))
@@ -447,9 +518,10 @@ func Decode(r io.Reader) (*Beatmap, error) {
 					b.UnhandledEvents = append(b.UnhandledEvents, line)
 				}
 			case "2", "break":
+				sbParser.reset()
 				if len(parts) >= 3 {
-					start := parseFloat(parts[1], 0) + float64(offset)
-					end := parseFloat(parts[2], start) + float64(offset)
+					start := d.float(parts[1], 0) + float64(offset)
+					end := d.float(parts[2], start) + float64(offset)
 					if end < start {
 						end = start
 					}
@@ -458,34 +530,37 @@ func Decode(r io.Reader) (*Beatmap, error) {
 					b.UnhandledEvents = append(b.UnhandledEvents, line)
 				}
 			default:
-				b.UnhandledEvents = append(b.UnhandledEvents, line)
+				if !sbParser.parseLine(raw) {
+					b.UnhandledEvents = append(b.UnhandledEvents, line)
+				}
 			}
 
 		case secTimingPoints:
 			parts := splitCSV(line)
 			if len(parts) < 2 {
+				d.warn("timing point needs at least 2 fields, got %d", len(parts))
 				continue
 			}
-			t := parseInt(parts[0], 0) + offset
-			beatLen := parseFloatAllowNaN(parts[1])
+			t := d.int(parts[0], 0) + offset
+			beatLen := d.floatAllowNaN(parts[1])
 			meter := 4
 			if len(parts) >= 3 {
-				meter = parseInt(parts[2], 4)
+				meter = d.int(parts[2], 4)
 				if meter == 0 {
 					meter = 4
 				}
 			}
 			sampleSet := "normal"
 			if len(parts) >= 4 {
-				sampleSet = normaliseSampleSet(parseInt(parts[3], 0))
+				sampleSet = normaliseSampleSet(d.int(parts[3], 0))
 			}
 			custom := 0
 			if len(parts) >= 5 {
-				custom = parseInt(parts[4], 0)
+				custom = d.int(parts[4], 0)
 			}
 			sampleVol := 100
 			if len(parts) >= 6 {
-				sampleVol = parseInt(parts[5], 100)
+				sampleVol = d.int(parts[5], 100)
 			}
 			timingChange := true
 			if len(parts) >= 7 {
@@ -493,7 +568,7 @@ func Decode(r io.Reader) (*Beatmap, error) {
 			}
 			kiai, omitFirstBar := false, false
 			if len(parts) >= 8 {
-				e := parseInt(parts[7], 0)
+				e := d.int(parts[7], 0)
 				if e&1 != 0 {
 					kiai = true
 				}
@@ -518,24 +593,44 @@ func Decode(r io.Reader) (*Beatmap, error) {
 				Kiai: kiai, OmitFirstBarSignature: omitFirstBar, SliderVelocityMultiplier: sv, ScrollSpeed: scroll,
 			})
 
+		case secColours:
+			k, v := splitKeyVal(line)
+			colour, ok := parseColour(v)
+			if !ok {
+				d.warn("invalid colour %q", v)
+				continue
+			}
+			switch {
+			case strings.HasPrefix(strings.ToLower(k), "combo"):
+				b.Colours.Combo = append(b.Colours.Combo, colour)
+			case strings.EqualFold(k, "slidertrackoverride"):
+				b.Colours.SliderTrackOverride = &colour
+			case strings.EqualFold(k, "sliderborder"):
+				b.Colours.SliderBorder = &colour
+			}
+
 		case secHitObjects:
 			parts := splitCSVPreserveTail(line, 11) // keep trailing parameters grouped
 			if len(parts) < 5 {
+				d.warn("hit object needs at least 5 fields, got %d", len(parts))
 				continue
 			}
-			x := parseInt(parts[0], 0)
-			y := parseInt(parts[1], 0)
-			t := parseInt(parts[2], 0) + offset
-			flags := HitObjectTypeFlags(parseInt(parts[3], 0))
-			hs := HitSoundFlags(parseInt(parts[4], 0))
+			x := d.int(parts[0], 0)
+			y := d.int(parts[1], 0)
+			t := d.int(parts[2], 0) + offset
+			flags := HitObjectTypeFlags(d.int(parts[3], 0))
+			hs := HitSoundFlags(d.int(parts[4], 0))
 
 			base := BaseHO{PosXY: Vec2{X: x, Y: y}, Time: t, Type: flags, Sound: hs}
+			if flags&(TypeCircle|TypeSlider|TypeSpinner|TypeHold) == 0 {
+				d.warn("hit object type %d has no object bit, read as a circle", flags)
+			}
 
 			switch {
 			case (flags & TypeHold) != 0:
 				// mania hold: "endTime:sample"
 				if len(parts) >= 6 {
-					end, samp := parseEndTimeAndSample(parts[5])
+					end, samp := d.endTimeAndSample(parts[5])
 					base.SampleHS = samp
 					b.HitObjects = append(b.HitObjects, Hold{BaseHO: base, EndTime: end + offset})
 				} else {
@@ -545,10 +640,10 @@ func Decode(r io.Reader) (*Beatmap, error) {
 			case (flags & TypeSpinner) != 0:
 				end := 0
 				if len(parts) >= 6 && strings.TrimSpace(parts[5]) != "" {
-					end = parseInt(parts[5], 0) + offset
+					end = d.int(parts[5], 0) + offset
 				}
 				if len(parts) >= 7 {
-					base.SampleHS = parseHitSample(parts[6])
+					base.SampleHS = d.hitSample(parts[6])
 				}
 				b.HitObjects = append(b.HitObjects, Spinner{BaseHO: base, EndTime: end})
 
@@ -560,32 +655,32 @@ func Decode(r io.Reader) (*Beatmap, error) {
 				}
 				slides := 1
 				if len(parts) >= 7 && strings.TrimSpace(parts[6]) != "" {
-					slides = parseInt(parts[6], 1)
+					slides = d.int(parts[6], 1)
 				}
 				length := 0.0
 				if len(parts) >= 8 && strings.TrimSpace(parts[7]) != "" {
-					length = parseFloat(parts[7], 0)
+					length = d.float(parts[7], 0)
 				}
 
 				var edgeSounds []HitSoundFlags
 				if len(parts) >= 9 && strings.TrimSpace(parts[8]) != "" {
 					for _, n := range strings.Split(parts[8], "|") {
-						edgeSounds = append(edgeSounds, HitSoundFlags(parseInt(n, 0)))
+						edgeSounds = append(edgeSounds, HitSoundFlags(d.int(n, 0)))
 					}
 				}
 				var edgeAdds []EdgeAdd
 				if len(parts) >= 10 && strings.TrimSpace(parts[9]) != "" {
 					for _, p := range strings.Split(parts[9], "|") {
-						ns, as := parseEdgeAddPair(p)
+						ns, as := d.edgeAddPair(p)
 						edgeAdds = append(edgeAdds, EdgeAdd{NormalSet: ns, AdditionSet: as})
 					}
 				}
 				// trailing hitSample (may be in parts[6+] depending on presence)
 				if len(parts) >= 11 {
-					base.SampleHS = parseHitSample(parts[10])
+					base.SampleHS = d.hitSample(parts[10])
 				}
 
-				path := parseSliderPath(base.PosXY, pathSpec) // fully parsed (no strings)
+				path := d.sliderPath(base.PosXY, pathSpec) // fully parsed (no strings)
 				b.HitObjects = append(b.HitObjects, Slider{
 					BaseHO:        base,
 					Path:          path,
@@ -598,7 +693,7 @@ func Decode(r io.Reader) (*Beatmap, error) {
 			default:
 				// Circle
 				if len(parts) >= 6 {
-					base.SampleHS = parseHitSample(parts[5])
+					base.SampleHS = d.hitSample(parts[5])
 				}
 				b.HitObjects = append(b.HitObjects, Circle{BaseHO: base})
 			}
@@ -609,9 +704,27 @@ func Decode(r io.Reader) (*Beatmap, error) {
 	}
 
 	applyDifficultyRestrictions(&b.Difficulty, b.General.Mode)
+	computeCombos(b)
+	if opts.Strict && len(d.diagnostics) > 0 {
+		return nil, &DecodeError{Diagnostics: d.diagnostics}
+	}
+	b.Warnings = d.diagnostics
 	return b, nil
 }
 
+// ComboColours returns the beatmap combo colours, or the default skin ones.
+func (b *Beatmap) ComboColours() []Colour {
+	if len(b.Colours.Combo) > 0 {
+		return b.Colours.Combo
+	}
+	return DefaultComboColours
+}
+
+func (b *Beatmap) ComboColour(ho HitObject) Colour {
+	colours := b.ComboColours()
+	return colours[ho.Combo().IndexWithOffsets%len(colours)]
+}
+
 // ---------- parsing helpers ----------
 
 func splitKeyVal(line string) (key, val string) {
@@ -734,7 +847,7 @@ func applyDifficultyRestrictions(d *Difficulty, mode int) {
 	d.HPDrainRate = clampFloat(d.HPDrainRate, 0, 10)
 	d.OverallDifficulty = clampFloat(d.OverallDifficulty, 0, 10)
 	d.ApproachRate = clampFloat(d.ApproachRate, 0, 10)
-	if mode == 3 {
+	if mode == ModeMania {
 		d.CircleSize = clampFloat(d.CircleSize, 1, MAX_MANIA_KEY_COUNT)
 	} else {
 		d.CircleSize = clampFloat(d.CircleSize, 0, 10)
@@ -743,9 +856,86 @@ func applyDifficultyRestrictions(d *Difficulty, mode int) {
 	d.SliderTickRate = clampFloat(d.SliderTickRate, 0.5, 8.0)
 }
 
+func parseColour(s string) (Colour, bool) {
+	// "r,g,b" or "r,g,b,a"; alpha is ignored
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 && len(parts) != 4 {
+		return Colour{}, false
+	}
+	var rgb [3]uint8
+	for i := range rgb {
+		v, err := strconv.Atoi(strings.TrimSpace(parts[i]))
+		if err != nil || v < 0 || v > 255 {
+			return Colour{}, false
+		}
+		rgb[i] = uint8(v)
+	}
+	return Colour{R: rgb[0], G: rgb[1], B: rgb[2]}, true
+}
+
+// computeCombos mirrors lazer: spinners never start a combo themselves,
+// they force one on the next object and pass their skip offset on to it.
+func computeCombos(b *Beatmap) {
+	var last *Combo
+	forceNewCombo := false
+	extraOffset := 0
+	for i, ho := range b.HitObjects {
+		newCombo := ho.NewCombo()
+		offset := ho.ComboSkip()
+		if ho.Kind() == KindSpinner {
+			forceNewCombo = forceNewCombo || b.FormatVersion <= 8 || newCombo
+			extraOffset += offset
+			newCombo, offset = false, 0
+		} else {
+			newCombo = newCombo || forceNewCombo || i == 0
+			offset += extraOffset
+			forceNewCombo, extraOffset = false, 0
+		}
+
+		c := Combo{Number: 1}
+		if last != nil {
+			c = Combo{Number: last.Number + 1, Index: last.Index, IndexWithOffsets: last.IndexWithOffsets}
+		}
+		if newCombo || last == nil {
+			c.Number = 1
+			c.Index++
+			c.IndexWithOffsets += offset + 1
+			if last != nil {
+				last.LastInCombo = true
+			}
+		}
+
+		if last != nil {
+			b.HitObjects[i-1] = withCombo(b.HitObjects[i-1], *last)
+		}
+		last = &c
+	}
+	if last != nil {
+		b.HitObjects[len(b.HitObjects)-1] = withCombo(b.HitObjects[len(b.HitObjects)-1], *last)
+	}
+}
+
+func withCombo(ho HitObject, c Combo) HitObject {
+	switch o := ho.(type) {
+	case Circle:
+		o.ComboInfo = c
+		return o
+	case Slider:
+		o.ComboInfo = c
+		return o
+	case Spinner:
+		o.ComboInfo = c
+		return o
+	case Hold:
+		o.ComboInfo = c
+		return o
+	}
+	return ho
+}
+
 // --- object-param parsing (typed, no raw strings) ---
 
-func parseHitSample(s string) HitSampleSpec {
+func (d *lineDecoder) hitSample(s string) HitSampleSpec {
 	// normalSet:additionSet:customIndex:volume:filename
 	parts := strings.Split(s, ":")
 	get := func(i int) string {
@@ -755,10 +945,10 @@ func parseHitSample(s string) HitSampleSpec {
 		return ""
 	}
 	ss := HitSampleSpec{}
-	ss.NormalSet = toSampleSet(parseInt(get(0), 0))
-	ss.AdditionSet = toSampleSet(parseInt(get(1), 0))
-	ss.Index = parseInt(get(2), 0)
-	ss.Volume = parseInt(get(3), 0)
+	ss.NormalSet = toSampleSet(d.int(get(0), 0))
+	ss.AdditionSet = toSampleSet(d.int(get(1), 0))
+	ss.Index = d.int(get(2), 0)
+	ss.Volume = d.int(get(3), 0)
 	ss.Filename = strings.Trim(get(4), " ")
 	ss.Filename = strings.Trim(ss.Filename, "\"")
 	return ss
@@ -777,33 +967,33 @@ func toSampleSet(id int) SampleSet {
 	}
 }
 
-func parseEdgeAddPair(s string) (SampleSet, SampleSet) {
+func (d *lineDecoder) edgeAddPair(s string) (SampleSet, SampleSet) {
 	// "x:y"
 	p := strings.Split(s, ":")
 	var a, b int
 	if len(p) >= 1 {
-		a = parseInt(p[0], 0)
+		a = d.int(p[0], 0)
 	}
 	if len(p) >= 2 {
-		b = parseInt(p[1], 0)
+		b = d.int(p[1], 0)
 	}
 	return toSampleSet(a), toSampleSet(b)
 }
 
-func parseEndTimeAndSample(s string) (int, HitSampleSpec) {
+func (d *lineDecoder) endTimeAndSample(s string) (int, HitSampleSpec) {
 	// "endTime:hitSampleSpec"
 	colon := strings.Index(s, ":")
 	if colon < 0 {
-		return parseInt(s, 0), HitSampleSpec{}
+		return d.int(s, 0), HitSampleSpec{}
 	}
-	end := parseInt(s[:colon], 0)
-	return end, parseHitSample(s[colon+1:])
+	end := d.int(s[:colon], 0)
+	return end, d.hitSample(s[colon+1:])
 }
 
-// parseSliderPath converts "B|x:y|x:y|..." into a fully-typed SliderPath.
+// sliderPath converts "B|x:y|x:y|..." into a fully-typed SliderPath.
 // The slider head (base) is the FIRST point; the string supplies the rest.
 // Bézier: split into segments when a control point repeats (red anchor). :contentReference[oaicite:1]{index=1}
-func parseSliderPath(head Vec2, spec string) SliderPath {
+func (d *lineDecoder) sliderPath(head Vec2, spec string) SliderPath {
 	spec = strings.TrimSpace(spec)
 	if spec == "" {
 		return SliderPath{Type: PathBezier, Segments: []SliderSegment{{Points: []Vec2{head}}}}
@@ -819,7 +1009,9 @@ func parseSliderPath(head Vec2, spec string) SliderPath {
 		typeStr, rest = spec[:tokEnd], spec[tokEnd+1:]
 	}
 	var pType SliderPathType
-	switch strings.ToUpper(strings.TrimSpace(typeStr)) {
+	degree := 0
+	typeStr = strings.ToUpper(strings.TrimSpace(typeStr))
+	switch typeStr {
 	case "L":
 		pType = PathLinear
 	case "C":
@@ -828,6 +1020,11 @@ func parseSliderPath(head Vec2, spec string) SliderPath {
 		pType = PathPerfect
 	default:
 		pType = PathBezier
+		// lazer: "B" followed by a positive degree is a B-spline, anything else a Bezier
+		if n, err := strconv.Atoi(strings.TrimPrefix(typeStr, "B")); strings.HasPrefix(typeStr, "B") && err == nil && n > 0 {
+			pType = PathBSpline
+			degree = n
+		}
 	}
 
 	// Parse control points
@@ -836,9 +1033,10 @@ func parseSliderPath(head Vec2, spec string) SliderPath {
 		for _, t := range strings.Split(rest, "|") {
 			xy := strings.Split(strings.TrimSpace(t), ":")
 			if len(xy) != 2 {
+				d.warn("invalid slider control point %q", t)
 				continue
 			}
-			cps = append(cps, Vec2{X: parseInt(xy[0], head.X), Y: parseInt(xy[1], head.Y)})
+			cps = append(cps, Vec2{X: d.int(xy[0], head.X), Y: d.int(xy[1], head.Y)})
 		}
 	}
 
@@ -853,11 +1051,17 @@ func parseSliderPath(head Vec2, spec string) SliderPath {
 		return SliderPath{Type: PathLinear, Segments: []SliderSegment{{Points: append([]Vec2{head}, cps...)}}}
 	case PathCatmull:
 		return SliderPath{Type: PathCatmull, Segments: []SliderSegment{{Points: append([]Vec2{head}, cps...)}}}
+	case PathBSpline:
+		path := buildBezierWithSegments(head, cps)
+		path.Type = PathBSpline
+		path.Degree = degree
+		return path
 	default: // Bezier (with segment splitting by repeated points)
 		return buildBezierWithSegments(head, cps)
 	}
 }
 
+// buildBezierWithSegments splits the points at red anchors; B-splines reuse it and set their type.
 func buildBezierWithSegments(head Vec2, cps []Vec2) SliderPath {
 	pts := append([]Vec2{head}, cps...)
 	var segs []SliderSegment
@@ -875,7 +1079,8 @@ func buildBezierWithSegments(head Vec2, cps []Vec2) SliderPath {
 		}
 		cur = append(cur, p)
 	}
-	if len(cur) >= 2 {
+	// a red anchor on the end leaves a lone point; it is kept, as stable never extends such a path
+	if len(cur) >= 2 || len(segs) > 0 {
 		segs = append(segs, SliderSegment{Points: cur})
 	}
 	if len(segs) == 0 {
@@ -0,0 +1,125 @@
+package dotosu
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComboInformation(t *testing.T) {
+	b, err := Decode(strings.NewReader(sampleMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Combo{
+		{Number: 1, Index: 1, IndexWithOffsets: 1},
+		{Number: 2, Index: 1, IndexWithOffsets: 1, LastInCombo: true},
+		{Number: 1, Index: 2, IndexWithOffsets: 2},
+		{Number: 2, Index: 2, IndexWithOffsets: 2},
+		{Number: 3, Index: 2, IndexWithOffsets: 2},
+		{Number: 4, Index: 2, IndexWithOffsets: 2}, // spinners never start a combo
+	}
+	for i, ho := range b.HitObjects {
+		if ho.Combo() != want[i] {
+			t.Errorf("object %d: got %+v, want %+v", i, ho.Combo(), want[i])
+		}
+	}
+
+	if len(b.Colours.Combo) != 2 || b.Colours.SliderBorder == nil {
+		t.Fatalf("colours not parsed: %+v", b.Colours)
+	}
+	if got := b.ComboColour(b.HitObjects[2]); got != (Colour{R: 255}) {
+		t.Errorf("combo colour: got %+v", got)
+	}
+}
+
+func TestComboSkipAfterSpinner(t *testing.T) {
+	const objects = `osu file format v14
+
+[HitObjects]
+256,192,0,5,0
+256,192,100,12,0,500
+256,192,600,1,0
+256,192,700,37,0
+`
+	b, err := Decode(strings.NewReader(objects))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the spinner forces a new combo on the next circle, which then skips two colours
+	if c := b.HitObjects[2].Combo(); c.Number != 1 || c.IndexWithOffsets != 2 {
+		t.Errorf("after spinner: %+v", c)
+	}
+	if c := b.HitObjects[3].Combo(); c.Number != 1 || c.IndexWithOffsets != 5 {
+		t.Errorf("combo skip: %+v", c)
+	}
+}
+
+func TestDecodeDiagnostics(t *testing.T) {
+	const broken = `osu file format v14
+
+[Difficulty]
+CircleSize:four
+
+[TimingPoints]
+0
+
+[HitObjects]
+256,192,1000,1,0
+256,192
+256,x,2000,1,0
+`
+	b, err := Decode(strings.NewReader(broken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.HitObjects) != 2 {
+		t.Errorf("lenient decode kept %d hit objects, want 2", len(b.HitObjects))
+	}
+	want := []Diagnostic{
+		{Section: "Difficulty", Line: 4, Raw: "CircleSize:four", Reason: `invalid number "four"`},
+		{Section: "TimingPoints", Line: 7, Raw: "0", Reason: "timing point needs at least 2 fields, got 1"},
+		{Section: "HitObjects", Line: 11, Raw: "256,192", Reason: "hit object needs at least 5 fields, got 2"},
+		{Section: "HitObjects", Line: 12, Raw: "256,x,2000,1,0", Reason: `invalid integer "x"`},
+	}
+	if len(b.Warnings) != len(want) {
+		t.Fatalf("warnings: %v", b.Warnings)
+	}
+	for i := range want {
+		if b.Warnings[i] != want[i] {
+			t.Errorf("warning %d: got %v, want %v", i, b.Warnings[i], want[i])
+		}
+	}
+
+	_, err = DecodeWithOptions(strings.NewReader(broken), DecodeOptions{Strict: true})
+	decodeErr, ok := err.(*DecodeError)
+	if !ok || len(decodeErr.Diagnostics) != len(want) {
+		t.Fatalf("strict decode: %v", err)
+	}
+}
+
+func TestBSplinePath(t *testing.T) {
+	const objects = `osu file format v14
+
+[HitObjects]
+0,0,0,2,0,B3|100:0|100:100|100:100|0:100,1,300
+0,0,500,2,0,B|100:0|100:100,1,200
+0,0,1000,2,0,B0|100:0,1,100
+`
+	b, err := Decode(strings.NewReader(objects))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spline := b.HitObjects[0].(Slider).Path
+	if spline.Type != PathBSpline || spline.Degree != 3 || len(spline.Segments) != 2 {
+		t.Errorf("B3 path: %+v", spline)
+	}
+	// a bare "B", or one without a positive degree, stays a Bezier
+	for _, ho := range b.HitObjects[1:] {
+		if p := ho.(Slider).Path; p.Type != PathBezier {
+			t.Errorf("object at %d: %+v", ho.StartTime(), p)
+		}
+	}
+	if got := encodeSliderPath(spline); got != "B3|100:0|100:100|100:100|0:100" {
+		t.Errorf("encoded as %q", got)
+	}
+}
@@ -0,0 +1,353 @@
+package dotosu
+
+import (
+	"errors"
+	"math"
+)
+
+// General.Mode values
+const (
+	ModeOsu = iota
+	ModeTaiko
+	ModeCatch
+	ModeMania
+)
+
+const (
+	LEGACY_TAIKO_VELOCITY_MULTIPLIER = 1.4
+	SWELL_HIT_MULTIPLIER             = 1.65
+)
+
+// PathPosition maps a slider and a progress along its path (0 head, 1 end) to a playfield position.
+// Path approximation lives outside of this package, so conversions that need it take one of these.
+type PathPosition func(s Slider, progress float64) (x, y float64)
+
+// difficultyRange mirrors lazer IBeatmapDifficultyInfo.DifficultyRange.
+func difficultyRange(difficulty, min, mid, max float64) float64 {
+	if difficulty > 5 {
+		return mid + (max-mid)*(difficulty-5)/5
+	}
+	if difficulty < 5 {
+		return mid - (mid-min)*(5-difficulty)/5
+	}
+	return mid
+}
+
+// nodeSounds returns the hitsound of every slider node (head, repeats, tail),
+// falling back to the object hitsound like lazer does when edge sounds are missing.
+func nodeSounds(s Slider) []HitSoundFlags {
+	out := make([]HitSoundFlags, max(1, s.Slides)+1)
+	for i := range out {
+		if i < len(s.EdgeSounds) {
+			out[i] = s.EdgeSounds[i]
+		} else {
+			out[i] = s.Sound
+		}
+	}
+	return out
+}
+
+// ---------- taiko ----------
+
+type TaikoKind uint8
+
+const (
+	TaikoDon TaikoKind = iota
+	TaikoKat
+	TaikoDrumRoll
+	TaikoSwell
+)
+
+type TaikoObject interface {
+	Kind() TaikoKind
+	StartTime() float64
+}
+
+type TaikoHit struct {
+	Time   float64
+	Rim    bool // kat
+	Strong bool
+}
+
+func (h TaikoHit) Kind() TaikoKind {
+	if h.Rim {
+		return TaikoKat
+	}
+	return TaikoDon
+}
+func (h TaikoHit) StartTime() float64 { return h.Time }
+
+type TaikoDrumRollObject struct {
+	Time     float64
+	EndTime  float64
+	Strong   bool
+	TickRate int
+}
+
+func (TaikoDrumRollObject) Kind() TaikoKind      { return TaikoDrumRoll }
+func (d TaikoDrumRollObject) StartTime() float64 { return d.Time }
+
+type TaikoSwellObject struct {
+	Time         float64
+	EndTime      float64
+	RequiredHits int
+}
+
+func (TaikoSwellObject) Kind() TaikoKind      { return TaikoSwell }
+func (s TaikoSwellObject) StartTime() float64 { return s.Time }
+
+func taikoHitFromSound(t float64, sound HitSoundFlags) TaikoHit {
+	return TaikoHit{
+		Time:   t,
+		Rim:    sound&(HitSoundWhistle|HitSoundClap) != 0,
+		Strong: sound&HitSoundFinish != 0,
+	}
+}
+
+// ToTaiko follows lazer's TaikoBeatmapConverter, including the stable rules for
+// splitting short osu!standard sliders into hits.
+func ToTaiko(b *Beatmap) []TaikoObject {
+	out := make([]TaikoObject, 0, len(b.HitObjects))
+	controlPoints := b.ControlPoints()
+	for _, ho := range b.HitObjects {
+		switch o := ho.(type) {
+		case Slider:
+			out = append(out, b.convertTaikoSlider(controlPoints, o)...)
+		case Spinner:
+			hitMultiplier := difficultyRange(b.Difficulty.OverallDifficulty, 3, 5, 7.5) * SWELL_HIT_MULTIPLIER
+			duration := float64(o.EndTime - o.Time)
+			out = append(out, TaikoSwellObject{
+				Time:         float64(o.Time),
+				EndTime:      float64(o.EndTime),
+				RequiredHits: int(math.Max(1, duration/1000*hitMultiplier)),
+			})
+		case Hold:
+			out = append(out, TaikoDrumRollObject{
+				Time:     float64(o.Time),
+				EndTime:  float64(o.EndTime),
+				Strong:   o.Sound&HitSoundFinish != 0,
+				TickRate: taikoTickRate(b),
+			})
+		default:
+			out = append(out, taikoHitFromSound(float64(ho.StartTime()), ho.HitSound()))
+		}
+	}
+	return out
+}
+
+func taikoTickRate(b *Beatmap) int {
+	if b.Difficulty.SliderTickRate == 3 {
+		return 3
+	}
+	return 4
+}
+
+func (b *Beatmap) convertTaikoSlider(controlPoints *ControlPoints, s Slider) []TaikoObject {
+	// the float juggling below is kept as is for 1:1 compatibility with stable
+	spans := max(1, s.Slides)
+	distance := s.Length * float64(spans) * LEGACY_TAIKO_VELOCITY_MULTIPLIER
+
+	timingBeatLength := controlPoints.BeatLengthAt(float64(s.Time))
+	sv := controlPoints.SliderVelocityAt(float64(s.Time))
+	beatLength := timingBeatLength / sv
+
+	sliderScoringPointDistance := BASE_SCORING_DISTANCE * b.Difficulty.SliderMultiplier / b.Difficulty.SliderTickRate
+	taikoVelocity := sliderScoringPointDistance * b.Difficulty.SliderTickRate
+	taikoDuration := float64(int(distance / taikoVelocity * beatLength))
+
+	drumRoll := []TaikoObject{TaikoDrumRollObject{
+		Time:     float64(s.Time),
+		EndTime:  float64(s.Time) + taikoDuration,
+		Strong:   s.Sound&HitSoundFinish != 0,
+		TickRate: taikoTickRate(b),
+	}}
+	if b.General.Mode == ModeTaiko {
+		return drumRoll
+	}
+
+	osuVelocity := taikoVelocity * (1000 / beatLength)
+	if b.FormatVersion >= 8 {
+		beatLength = timingBeatLength
+	}
+	tickSpacing := math.Min(beatLength/b.Difficulty.SliderTickRate, taikoDuration/float64(spans))
+
+	if !(tickSpacing > 0 && distance/osuVelocity*1000 < 2*beatLength) {
+		return drumRoll
+	}
+
+	sounds := nodeSounds(s)
+	var out []TaikoObject
+	i := 0
+	start := float64(s.Time)
+	for t := start; t <= start+taikoDuration+tickSpacing/8; t += tickSpacing {
+		out = append(out, taikoHitFromSound(t, sounds[i]))
+		i = (i + 1) % len(sounds)
+		if math.Abs(tickSpacing) < 1e-3 {
+			break
+		}
+	}
+	return out
+}
+
+// ---------- catch ----------
+
+type CatchKind uint8
+
+const (
+	CatchFruit CatchKind = iota
+	CatchDroplet
+	CatchTinyDroplet
+	CatchBanana
+)
+
+type CatchObject struct {
+	Kind CatchKind
+	Time float64
+	X    float64
+}
+
+// ToCatch follows lazer's CatchBeatmapConverter and JuiceStream nesting.
+// Catch's random position offsets (bananas, Hard Rock) are not applied.
+func ToCatch(b *Beatmap, path PathPosition) []CatchObject {
+	out := make([]CatchObject, 0, len(b.HitObjects))
+	clampX := func(x float64) float64 { return clampFloat(x, 0, 512) }
+	controlPoints := b.ControlPoints()
+	for _, ho := range b.HitObjects {
+		switch o := ho.(type) {
+		case Slider:
+			xAt := func(progress float64) float64 {
+				x, _ := path(o, progress)
+				return clampX(x)
+			}
+			var last *SliderEvent
+			for _, e := range b.SliderEvents(controlPoints, o) {
+				if last != nil {
+					// tiny droplets since the last event
+					sinceLast := float64(int(e.Time) - int(last.Time))
+					if sinceLast > 80 {
+						between := sinceLast
+						for between > 100 {
+							between /= 2
+						}
+						for t := between; t < sinceLast; t += between {
+							progress := last.PathProgress + (t/sinceLast)*(e.PathProgress-last.PathProgress)
+							out = append(out, CatchObject{Kind: CatchTinyDroplet, Time: t + last.Time, X: xAt(progress)})
+						}
+					}
+				}
+				// the legacy last tick only shifts tiny droplets, like in lazer
+				last = &e
+				switch e.Type {
+				case SliderEventTick:
+					out = append(out, CatchObject{Kind: CatchDroplet, Time: e.Time, X: xAt(e.PathProgress)})
+				case SliderEventHead, SliderEventRepeat, SliderEventTail:
+					out = append(out, CatchObject{Kind: CatchFruit, Time: e.Time, X: xAt(e.PathProgress)})
+				}
+			}
+		case Spinner:
+			out = appendBananas(out, float64(o.Time), float64(o.EndTime))
+		case Hold:
+			out = appendBananas(out, float64(o.Time), float64(o.EndTime))
+		default:
+			out = append(out, CatchObject{Kind: CatchFruit, Time: float64(ho.StartTime()), X: clampX(float64(ho.Pos().X))})
+		}
+	}
+	return out
+}
+
+// appendBananas mirrors lazer's BananaShower nesting.
+func appendBananas(out []CatchObject, start, end float64) []CatchObject {
+	spacing := end - start
+	for spacing > 100 {
+		spacing /= 2
+	}
+	if spacing <= 0 {
+		return out
+	}
+	for t := start; t <= end; t += spacing {
+		out = append(out, CatchObject{Kind: CatchBanana, Time: t, X: 256})
+	}
+	return out
+}
+
+// ---------- mania ----------
+
+type ManiaNote struct {
+	Column  int
+	Time    float64
+	EndTime float64 // equal to Time for plain notes
+}
+
+func (n ManiaNote) IsHold() bool { return n.EndTime > n.Time }
+
+type ManiaBeatmap struct {
+	KeyCount int
+	Notes    []ManiaNote
+}
+
+// ManiaKeyCount mirrors lazer's ManiaBeatmapConverter.GetColumnCount.
+func ManiaKeyCount(b *Beatmap) int {
+	roundedCS := math.RoundToEven(b.Difficulty.CircleSize)
+	roundedOD := math.RoundToEven(b.Difficulty.OverallDifficulty)
+	if b.General.Mode == ModeMania {
+		return int(math.Max(1, roundedCS))
+	}
+	if len(b.HitObjects) == 0 {
+		return 7
+	}
+
+	withDuration := 0
+	for _, ho := range b.HitObjects {
+		if ho.Kind() == KindSlider || ho.Kind() == KindSpinner {
+			withDuration++
+		}
+	}
+	percentSliderOrSpinner := float64(withDuration) / float64(len(b.HitObjects))
+
+	switch {
+	case percentSliderOrSpinner < 0.2:
+		return 7
+	case percentSliderOrSpinner < 0.3 || roundedCS >= 5:
+		if roundedOD > 5 {
+			return 7
+		}
+		return 6
+	case percentSliderOrSpinner > 0.6:
+		if roundedOD > 4 {
+			return 5
+		}
+		return 4
+	default:
+		return max(4, min(int(roundedOD)+1, 7))
+	}
+}
+
+func ManiaColumn(x int, keyCount int) int {
+	return clampInt(int(math.Floor(float64(x)/(512.0/float64(keyCount)))), 0, keyCount-1)
+}
+
+// ErrManiaConvert is returned by ToMania for taiko and catch maps, which lazer doesn't convert.
+var ErrManiaConvert = errors.New("only osu!standard maps convert to mania")
+
+// ToMania reads the notes of a mania map, columns by X and holds keeping their end, and
+// converts osu!standard maps, see convertToMania.
+func ToMania(b *Beatmap) (ManiaBeatmap, error) {
+	switch b.General.Mode {
+	case ModeOsu:
+		return convertToMania(b)
+	case ModeMania:
+	default:
+		return ManiaBeatmap{}, ErrManiaConvert
+	}
+	keyCount := ManiaKeyCount(b)
+	out := ManiaBeatmap{KeyCount: keyCount, Notes: make([]ManiaNote, 0, len(b.HitObjects))}
+	for _, ho := range b.HitObjects {
+		start := float64(ho.StartTime())
+		note := ManiaNote{Column: ManiaColumn(ho.Pos().X, keyCount), Time: start, EndTime: start}
+		if o, ok := ho.(Hold); ok {
+			note.EndTime = float64(o.EndTime)
+		}
+		out.Notes = append(out.Notes, note)
+	}
+	return out, nil
+}
@@ -0,0 +1,184 @@
+package dotosu
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const convertMap = `osu file format v14
+
+[General]
+Mode: 0
+
+[Difficulty]
+CircleSize:4
+OverallDifficulty:5
+SliderMultiplier:1
+SliderTickRate:1
+
+[TimingPoints]
+0,500,4,1,0,100,1,0
+
+[HitObjects]
+64,192,0,1,0
+448,192,500,1,8
+0,192,1000,2,0,L|200:192,1,200
+256,192,4000,8,4,6000
+`
+
+func TestConvertRulesets(t *testing.T) {
+	b, err := Decode(strings.NewReader(convertMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	taiko := ToTaiko(b)
+	if taiko[0].Kind() != TaikoDon || taiko[1].Kind() != TaikoKat {
+		t.Errorf("taiko hits: %+v", taiko[:2])
+	}
+	if taiko[len(taiko)-1].Kind() != TaikoSwell {
+		t.Errorf("taiko spinner: %+v", taiko[len(taiko)-1])
+	}
+
+	// the slider lasts 1000ms with a tick every 500ms
+	events := b.SliderEvents(b.ControlPoints(), b.HitObjects[2].(Slider))
+	var types []SliderEventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	want := []SliderEventType{SliderEventHead, SliderEventTick, SliderEventLegacyLastTick, SliderEventTail}
+	if len(types) != len(want) {
+		t.Fatalf("slider events: %+v", events)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("slider events: %+v", events)
+		}
+	}
+	if events[3].Time != 2000 || events[2].Time != 2000-LEGACY_LAST_TICK_OFFSET {
+		t.Errorf("slider end: %+v", events)
+	}
+
+	linear := func(s Slider, progress float64) (float64, float64) {
+		return float64(s.PosXY.X) + progress*s.Length, float64(s.PosXY.Y)
+	}
+	fruits, droplets := 0, 0
+	for _, o := range ToCatch(b, linear) {
+		switch o.Kind {
+		case CatchFruit:
+			fruits++
+		case CatchDroplet:
+			droplets++
+		}
+	}
+	if fruits != 4 || droplets != 1 {
+		t.Errorf("catch: %d fruits, %d droplets", fruits, droplets)
+	}
+
+	if got := ManiaKeyCount(b); got != 6 {
+		t.Errorf("key count: %d", got)
+	}
+	converted, err := ToMania(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkManiaNotes(t, converted)
+	// the clap asks for a second note, the slider and spinner are long enough for holds
+	var atClap []int
+	for _, note := range converted.Notes {
+		switch note.Time {
+		case 500:
+			atClap = append(atClap, note.Column)
+		case 1000:
+			if note.EndTime != 2000 {
+				t.Errorf("slider: %+v", note)
+			}
+		case 4000:
+			if note.EndTime != 6000 {
+				t.Errorf("spinner: %+v", note)
+			}
+		}
+	}
+	if len(atClap) != 2 || atClap[0] == atClap[1] {
+		t.Errorf("clap: columns %v", atClap)
+	}
+	again, _ := ToMania(b)
+	if !reflect.DeepEqual(again, converted) {
+		t.Error("converting twice differs")
+	}
+
+	b.General.Mode = ModeTaiko
+	if _, err := ToMania(b); err != ErrManiaConvert {
+		t.Errorf("converting taiko to mania: %v", err)
+	}
+
+	b.General.Mode = ModeMania
+	b.Difficulty.CircleSize = 4
+	b.HitObjects = []HitObject{
+		Circle{BaseHO{PosXY: Vec2{X: 64}, Time: 0}},
+		Hold{BaseHO: BaseHO{PosXY: Vec2{X: 448}, Time: 500}, EndTime: 900},
+	}
+	mania, err := ToMania(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mania.KeyCount != 4 || mania.Notes[0].Column != 0 || mania.Notes[1].Column != 3 || mania.Notes[1].EndTime != 900 {
+		t.Errorf("mania notes: %+v", mania)
+	}
+}
+
+// checkManiaNotes checks that notes are in their columns, in time order and never overlap.
+func checkManiaNotes(t *testing.T, m ManiaBeatmap) {
+	t.Helper()
+	busyUntil := map[int]float64{}
+	for i, note := range m.Notes {
+		if note.Column < 0 || note.Column >= m.KeyCount {
+			t.Errorf("note %d: column %d of %d", i, note.Column, m.KeyCount)
+		}
+		if i > 0 && note.Time < m.Notes[i-1].Time {
+			t.Errorf("note %d: out of order", i)
+		}
+		if end, ok := busyUntil[note.Column]; ok && note.Time <= end {
+			t.Errorf("note %d: overlaps column %d until %v", i, note.Column, end)
+		}
+		busyUntil[note.Column] = note.EndTime
+	}
+}
+
+func TestManiaConvertPatterns(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("osu file format v14\n\n[Difficulty]\nHPDrainRate:5\nCircleSize:4\nOverallDifficulty:8\nApproachRate:9\nSliderMultiplier:1\nSliderTickRate:1\n\n")
+	sb.WriteString("[TimingPoints]\n0,500,4,1,0,100,1,0\n\n[HitObjects]\n")
+	// a stream of 80ms, one note each
+	for i := range 8 {
+		fmt.Fprintf(&sb, "%d,192,%d,1,0\n", 100+i*40, 1000+i*80)
+	}
+	// a slider of 100ms spans, a note on every node
+	sb.WriteString("256,192,3000,2,0,L|276:192,4,20\n")
+	b, err := Decode(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := ToMania(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkManiaNotes(t, m)
+	if m.KeyCount != 7 || len(m.Notes) != 13 {
+		t.Fatalf("%d keys, %d notes: %+v", m.KeyCount, len(m.Notes), m.Notes)
+	}
+	for i, note := range m.Notes {
+		want := float64(1000 + i*80)
+		if i >= 8 {
+			want = float64(3000 + (i-8)*100)
+		}
+		if note.Time != want || note.IsHold() {
+			t.Errorf("note %d: %+v, want a note at %v", i, note, want)
+		}
+		if i > 0 && note.Column == m.Notes[i-1].Column {
+			t.Errorf("note %d: stacked on the one before in column %d", i, note.Column)
+		}
+	}
+}
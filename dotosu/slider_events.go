@@ -0,0 +1,164 @@
+package dotosu
+
+import "math"
+
+const (
+	BASE_SCORING_DISTANCE   = 100
+	LEGACY_LAST_TICK_OFFSET = 36
+	MAX_SLIDER_TICK_LENGTH  = 100000
+)
+
+// SliderTiming holds what lazer derives for a slider from the control points at its start.
+type SliderTiming struct {
+	BeatLength     float64
+	SliderVelocity float64
+	Velocity       float64 // osu!pixels per ms
+	TickDistance   float64
+	SpanDuration   float64
+	EndTime        float64
+}
+
+// SliderTiming takes the control points of b, see Beatmap.ControlPoints.
+func (b *Beatmap) SliderTiming(controlPoints *ControlPoints, s Slider) SliderTiming {
+	beatLength := controlPoints.BeatLengthAt(float64(s.Time))
+	difficulty := controlPoints.DifficultyAt(float64(s.Time))
+	sv := difficulty.SliderVelocity
+	scoringDistance := BASE_SCORING_DISTANCE * b.Difficulty.SliderMultiplier * sv
+	velocity := scoringDistance / beatLength
+	tickDistance := scoringDistance / b.Difficulty.SliderTickRate
+	if b.FormatVersion < 8 {
+		tickDistance /= sv
+	}
+	if !difficulty.GenerateTicks {
+		tickDistance = math.Inf(1)
+	}
+	spanDuration := s.Length / velocity
+	return SliderTiming{
+		BeatLength:     beatLength,
+		SliderVelocity: sv,
+		Velocity:       velocity,
+		TickDistance:   tickDistance,
+		SpanDuration:   spanDuration,
+		EndTime:        float64(s.Time) + float64(s.Slides)*spanDuration,
+	}
+}
+
+// ---------- lazer SliderEventGenerator ----------
+
+type SliderEventType uint8
+
+const (
+	SliderEventHead SliderEventType = iota
+	SliderEventTick
+	SliderEventRepeat
+	SliderEventLegacyLastTick
+	SliderEventTail
+)
+
+type SliderEvent struct {
+	Type          SliderEventType
+	SpanIndex     int
+	SpanStartTime float64
+	Time          float64
+	PathProgress  float64 // 0 = head, 1 = end of the path
+}
+
+// SliderEvents generates the nested events of a slider the way lazer's SliderEventGenerator does.
+func (b *Beatmap) SliderEvents(controlPoints *ControlPoints, s Slider) []SliderEvent {
+	t := b.SliderTiming(controlPoints, s)
+	return GenerateSliderEvents(float64(s.Time), t.SpanDuration, t.Velocity, t.TickDistance, s.Length, s.Slides)
+}
+
+func GenerateSliderEvents(
+	startTime float64,
+	spanDuration float64,
+	velocity float64,
+	tickDistance float64,
+	totalDistance float64,
+	spanCount int,
+) []SliderEvent {
+	spanCount = max(1, spanCount)
+	length := math.Min(MAX_SLIDER_TICK_LENGTH, totalDistance)
+	tickDistance = clampFloat(tickDistance, 0, length)
+	minDistanceFromEnd := velocity * 10
+
+	events := []SliderEvent{{
+		Type:          SliderEventHead,
+		SpanStartTime: startTime,
+		Time:          startTime,
+	}}
+
+	// like lazer, a slider without ticks has no repeats either
+	if tickDistance != 0 {
+		for span := range spanCount {
+			spanStartTime := startTime + float64(span)*spanDuration
+			reversed := span%2 == 1
+
+			var ticks []SliderEvent
+			for d := tickDistance; d <= length; d += tickDistance {
+				if d >= length-minDistanceFromEnd {
+					break
+				}
+				// ticks are placed from the start of the path so repeat spans share positions
+				pathProgress := d / length
+				timeProgress := pathProgress
+				if reversed {
+					timeProgress = 1 - pathProgress
+				}
+				ticks = append(ticks, SliderEvent{
+					Type:          SliderEventTick,
+					SpanIndex:     span,
+					SpanStartTime: spanStartTime,
+					Time:          spanStartTime + timeProgress*spanDuration,
+					PathProgress:  pathProgress,
+				})
+			}
+			if reversed {
+				for i, j := 0, len(ticks)-1; i < j; i, j = i+1, j-1 {
+					ticks[i], ticks[j] = ticks[j], ticks[i]
+				}
+			}
+			events = append(events, ticks...)
+
+			if span < spanCount-1 {
+				events = append(events, SliderEvent{
+					Type:          SliderEventRepeat,
+					SpanIndex:     span,
+					SpanStartTime: spanStartTime,
+					Time:          spanStartTime + spanDuration,
+					PathProgress:  float64((span + 1) % 2),
+				})
+			}
+		}
+	}
+
+	totalDuration := float64(spanCount) * spanDuration
+
+	// stable judged the end of the slider slightly early
+	finalSpanIndex := spanCount - 1
+	finalSpanStartTime := startTime + float64(finalSpanIndex)*spanDuration
+	finalSpanEndTime := math.Max(startTime+totalDuration/2, finalSpanStartTime+spanDuration-LEGACY_LAST_TICK_OFFSET)
+	finalProgress := 0.0
+	if spanDuration > 0 {
+		finalProgress = (finalSpanEndTime - finalSpanStartTime) / spanDuration
+	}
+	if spanCount%2 == 0 {
+		finalProgress = 1 - finalProgress
+	}
+	events = append(events, SliderEvent{
+		Type:          SliderEventLegacyLastTick,
+		SpanIndex:     finalSpanIndex,
+		SpanStartTime: finalSpanStartTime,
+		Time:          finalSpanEndTime,
+		PathProgress:  finalProgress,
+	})
+
+	events = append(events, SliderEvent{
+		Type:          SliderEventTail,
+		SpanIndex:     finalSpanIndex,
+		SpanStartTime: finalSpanStartTime,
+		Time:          startTime + totalDuration,
+		PathProgress:  float64(spanCount % 2),
+	})
+	return events
+}
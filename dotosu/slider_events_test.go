@@ -0,0 +1,39 @@
+package dotosu
+
+import "testing"
+
+func TestGenerateSliderEvents(t *testing.T) {
+	tests := []struct {
+		name          string
+		spanDuration  float64
+		tickDistance  float64
+		totalDistance float64
+		spanCount     int
+		want          []SliderEventType
+	}{
+		{"ticks and repeats", 500, 50, 100, 2, []SliderEventType{
+			SliderEventHead, SliderEventTick, SliderEventRepeat, SliderEventTick, SliderEventLegacyLastTick, SliderEventTail,
+		}},
+		// lazer generates no repeats without a tick distance
+		{"zero length with repeats", 0, 50, 0, 3, []SliderEventType{
+			SliderEventHead, SliderEventLegacyLastTick, SliderEventTail,
+		}},
+	}
+	for _, test := range tests {
+		events := GenerateSliderEvents(1000, test.spanDuration, 0.2, test.tickDistance, test.totalDistance, test.spanCount)
+		var got []SliderEventType
+		for _, e := range events {
+			got = append(got, e.Type)
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,753 @@
+package dotosu
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ---------- Storyboard model ----------
+
+type StoryboardLayer uint8
+
+const (
+	LayerBackground StoryboardLayer = iota
+	LayerFail
+	LayerPass
+	LayerForeground
+	LayerOverlay // the only layer drawn above hit objects
+)
+
+var layerNames = []string{"Background", "Fail", "Pass", "Foreground", "Overlay"}
+
+type Origin uint8
+
+const (
+	OriginTopLeft Origin = iota
+	OriginCentre
+	OriginCentreLeft
+	OriginTopRight
+	OriginBottomCentre
+	OriginTopCentre
+	OriginCustom
+	OriginCentreRight
+	OriginBottomLeft
+	OriginBottomRight
+)
+
+var originNames = []string{
+	"TopLeft", "Centre", "CentreLeft", "TopRight", "BottomCentre",
+	"TopCentre", "Custom", "CentreRight", "BottomLeft", "BottomRight",
+}
+
+type AnimationLoopType uint8
+
+const (
+	LoopForever AnimationLoopType = iota
+	LoopOnce
+)
+
+var loopTypeNames = []string{"LoopForever", "LoopOnce"}
+
+type CommandType uint8
+
+const (
+	CommandFade CommandType = iota
+	CommandMove
+	CommandMoveX
+	CommandMoveY
+	CommandScale
+	CommandVectorScale
+	CommandRotate
+	CommandColour
+	CommandParameter
+)
+
+var commandNames = []string{"F", "M", "MX", "MY", "S", "V", "R", "C", "P"}
+
+// number of values for each of start and end
+var commandValueCounts = []int{1, 2, 1, 1, 1, 2, 1, 3, 0}
+
+type Command struct {
+	Type      CommandType
+	Easing    int
+	StartTime float64
+	EndTime   float64
+	Start     []float64
+	End       []float64
+	Parameter string // P only: "H", "V" or "A"
+}
+
+type CommandLoop struct {
+	StartTime float64
+	Count     int
+	Commands  []Command // times relative to StartTime
+}
+
+type CommandTrigger struct {
+	Name      string // e.g. "HitSoundClap", "Passing", "Failing"
+	StartTime float64
+	EndTime   float64
+	Group     int
+	Commands  []Command // times relative to the trigger activation
+}
+
+type StoryboardElement interface {
+	ElementLayer() StoryboardLayer
+}
+
+type Sprite struct {
+	Layer    StoryboardLayer
+	Origin   Origin
+	Path     string
+	X, Y     float64
+	Commands []Command
+	Loops    []CommandLoop
+	Triggers []CommandTrigger
+}
+
+func (s *Sprite) ElementLayer() StoryboardLayer { return s.Layer }
+
+type Animation struct {
+	Sprite
+	FrameCount int
+	FrameDelay float64
+	LoopType   AnimationLoopType
+}
+
+// FramePaths returns the file of every frame: the index goes before the extension ("a.png" -> "a0.png").
+func (a *Animation) FramePaths() []string {
+	ext := path.Ext(a.Path)
+	base := strings.TrimSuffix(a.Path, ext)
+	out := make([]string, a.FrameCount)
+	for i := range out {
+		out[i] = base + strconv.Itoa(i) + ext
+	}
+	return out
+}
+
+type StoryboardSample struct {
+	Time   float64
+	Layer  StoryboardLayer
+	Path   string
+	Volume int
+}
+
+func (s *StoryboardSample) ElementLayer() StoryboardLayer { return s.Layer }
+
+// Storyboard elements are kept in file order, which is also the draw order within a layer.
+type Storyboard struct {
+	Elements []StoryboardElement
+
+	// problems skipped over by a lenient DecodeStoryboard; a beatmap's own storyboard reports
+	// them in Beatmap.Warnings
+	Warnings []Diagnostic
+}
+
+// ---------- Public API ----------
+
+func DecodeStoryboardFile(path string) (*Storyboard, error) {
+	return DecodeStoryboardFileWithOptions(path, DecodeOptions{})
+}
+
+func DecodeStoryboardFileWithOptions(path string, opts DecodeOptions) (*Storyboard, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeStoryboardWithOptions(f, opts)
+}
+
+// DecodeStoryboard reads a set-level .osb file ([Variables] and [Events] only).
+func DecodeStoryboard(r io.Reader) (*Storyboard, error) {
+	return DecodeStoryboardWithOptions(r, DecodeOptions{})
+}
+
+func DecodeStoryboardWithOptions(r io.Reader, opts DecodeOptions) (*Storyboard, error) {
+	sc := bufio.NewScanner(r)
+	const maxLine = 1024 * 1024
+	buf := make([]byte, 64*1024)
+	sc.Buffer(buf, maxLine)
+
+	sb := &Storyboard{}
+	d := &lineDecoder{}
+	p := newStoryboardParser(sb, d)
+	sec := secNone
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		raw := strings.TrimRight(strings.TrimPrefix(sc.Text(), "\ufeff"), " \t\r")
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			switch strings.ToLower(line) {
+			case "[variables]":
+				sec = secVariables
+			case "[events]":
+				sec = secEvents
+			default:
+				sec = secNone
+			}
+			continue
+		}
+		d.sec, d.line, d.raw = sec, lineNo, line
+		switch sec {
+		case secVariables:
+			p.parseVariable(line)
+		case secEvents:
+			p.parseLine(p.substitute(raw))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if opts.Strict && len(d.diagnostics) > 0 {
+		return nil, &DecodeError{Diagnostics: d.diagnostics}
+	}
+	sb.Warnings = d.diagnostics
+	return sb, nil
+}
+
+// Append adds the elements of another storyboard (e.g. the set .osb) after this one's.
+func (sb *Storyboard) Append(other *Storyboard) {
+	if other == nil {
+		return
+	}
+	sb.Elements = append(sb.Elements, other.Elements...)
+}
+
+func (sb *Storyboard) Sprites() []*Sprite {
+	var out []*Sprite
+	for _, e := range sb.Elements {
+		switch e := e.(type) {
+		case *Sprite:
+			out = append(out, e)
+		case *Animation:
+			out = append(out, &e.Sprite)
+		}
+	}
+	return out
+}
+
+// ActiveRange is the time span covered by the sprite's commands, loops unrolled.
+// Triggers are ignored since they depend on gameplay.
+func (s *Sprite) ActiveRange() (start, end float64, ok bool) {
+	include := func(from, to float64) {
+		if !ok || from < start {
+			start = from
+		}
+		if !ok || to > end {
+			end = to
+		}
+		ok = true
+	}
+	for _, c := range s.Commands {
+		include(c.StartTime, c.EndTime)
+	}
+	for _, l := range s.Loops {
+		if len(l.Commands) == 0 {
+			continue
+		}
+		first, last := l.Commands[0].StartTime, l.Commands[0].EndTime
+		for _, c := range l.Commands {
+			first = min(first, c.StartTime)
+			last = max(last, c.EndTime)
+		}
+		iterations := float64(max(1, l.Count))
+		include(l.StartTime+first, l.StartTime+first+(last-first)*iterations)
+	}
+	return start, end, ok
+}
+
+// MaxOpacity is the highest fade value the sprite reaches, 1 without fade commands.
+func (s *Sprite) MaxOpacity() float64 {
+	opacity, seen := 0.0, false
+	visit := func(cs []Command) {
+		for _, c := range cs {
+			if c.Type != CommandFade {
+				continue
+			}
+			seen = true
+			opacity = max(opacity, c.Start[0], c.End[0])
+		}
+	}
+	visit(s.Commands)
+	for _, l := range s.Loops {
+		visit(l.Commands)
+	}
+	if !seen {
+		return 1
+	}
+	return opacity
+}
+
+// storyboard space is 640x480, the 512x384 playfield sits in the middle of it
+const (
+	STORYBOARD_WIDTH  = 640
+	STORYBOARD_HEIGHT = 480
+	PLAYFIELD_WIDTH   = 512
+	PLAYFIELD_HEIGHT  = 384
+)
+
+// ImageSize returns the pixel size of a storyboard image, ok false when the file isn't available.
+type ImageSize func(path string) (width, height float64, ok bool)
+
+// FullscreenImages is the ImageSize used without the assets: every image is assumed to
+// fill the screen at scale 1.
+func FullscreenImages(string) (float64, float64, bool) {
+	return STORYBOARD_WIDTH, STORYBOARD_HEIGHT, true
+}
+
+// origin position as a fraction of the sprite size
+var originFractions = []struct{ X, Y float64 }{
+	OriginTopLeft:      {0, 0},
+	OriginCentre:       {0.5, 0.5},
+	OriginCentreLeft:   {0, 0.5},
+	OriginTopRight:     {1, 0},
+	OriginBottomCentre: {0.5, 1},
+	OriginTopCentre:    {0.5, 0},
+	OriginCustom:       {0, 0},
+	OriginCentreRight:  {1, 0.5},
+	OriginBottomLeft:   {0, 1},
+	OriginBottomRight:  {1, 1},
+}
+
+// commandValues returns the start and end values of every command of type t, loops included.
+func (s *Sprite) commandValues(t CommandType) [][]float64 {
+	var out [][]float64
+	visit := func(cs []Command) {
+		for _, c := range cs {
+			if c.Type == t {
+				out = append(out, c.Start, c.End)
+			}
+		}
+	}
+	visit(s.Commands)
+	for _, l := range s.Loops {
+		visit(l.Commands)
+	}
+	return out
+}
+
+// component picks the i-th value of every command value.
+func component(values [][]float64, i int) []float64 {
+	out := make([]float64, len(values))
+	for j, v := range values {
+		out[j] = v[i]
+	}
+	return out
+}
+
+// extent returns the lowest and highest value, or fallback for both when there are none.
+func extent(values []float64, fallback float64) (lo, hi float64) {
+	if len(values) == 0 {
+		return fallback, fallback
+	}
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+	return lo, hi
+}
+
+// CoversPlayfield reports whether the sprite hides the whole playfield wherever its move
+// commands take it and at its smallest scale. Rotation isn't taken into account.
+func (s *Sprite) CoversPlayfield(imagePath string, size ImageSize) bool {
+	width, height, ok := size(imagePath)
+	if !ok {
+		width, height, _ = FullscreenImages(imagePath)
+	}
+
+	vector := s.commandValues(CommandVectorScale)
+	scale, _ := extent(component(s.commandValues(CommandScale), 0), 1)
+	scaleX, _ := extent(component(vector, 0), 1)
+	scaleY, _ := extent(component(vector, 1), 1)
+	width *= math.Abs(scale * scaleX)
+	height *= math.Abs(scale * scaleY)
+
+	moves := s.commandValues(CommandMove)
+	minX, maxX := extent(append(component(moves, 0), component(s.commandValues(CommandMoveX), 0)...), s.X)
+	minY, maxY := extent(append(component(moves, 1), component(s.commandValues(CommandMoveY), 0)...), s.Y)
+
+	origin := originFractions[s.Origin]
+	left, top := float64(STORYBOARD_WIDTH-PLAYFIELD_WIDTH)/2, float64(STORYBOARD_HEIGHT-PLAYFIELD_HEIGHT)/2
+	right, bottom := left+PLAYFIELD_WIDTH, top+PLAYFIELD_HEIGHT
+	return maxX-origin.X*width <= left && minX+(1-origin.X)*width >= right &&
+		maxY-origin.Y*height <= top && minY+(1-origin.Y)*height >= bottom
+}
+
+// PlayfieldCover returns the merged time ranges where an Overlay sprite at least minOpacity
+// visible is large enough to hide the playfield (see CoversPlayfield). Pass the image sizes
+// from the set's assets, or FullscreenImages without them.
+func (sb *Storyboard) PlayfieldCover(minOpacity float64, size ImageSize) []BreakPeriod {
+	var ranges []BreakPeriod
+	for _, e := range sb.Elements {
+		var s *Sprite
+		imagePath := ""
+		switch e := e.(type) {
+		case *Sprite:
+			s, imagePath = e, e.Path
+		case *Animation:
+			s = &e.Sprite
+			if frames := e.FramePaths(); len(frames) > 0 {
+				imagePath = frames[0]
+			}
+		default:
+			continue
+		}
+		if s.Layer != LayerOverlay || s.MaxOpacity() < minOpacity || !s.CoversPlayfield(imagePath, size) {
+			continue
+		}
+		start, end, ok := s.ActiveRange()
+		if !ok || end <= start {
+			continue
+		}
+		ranges = append(ranges, BreakPeriod{Start: start, End: end})
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+
+	var merged []BreakPeriod
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r.Start <= merged[n-1].End {
+			merged[n-1].End = max(merged[n-1].End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// HidesPlayfield reports whether any hit object is played while the playfield is covered.
+// Append the set .osb to b.Storyboard first to take it into account.
+func (b *Beatmap) HidesPlayfield(minOpacity float64, size ImageSize) bool {
+	return len(b.playedUnder(b.Storyboard.PlayfieldCover(minOpacity, size))) > 0
+}
+
+// playedUnder returns the hit objects that start inside one of the merged cover ranges.
+func (b *Beatmap) playedUnder(cover []BreakPeriod) []HitObject {
+	if len(cover) == 0 {
+		return nil
+	}
+	var out []HitObject
+	for _, ho := range b.HitObjects {
+		t := float64(ho.StartTime())
+		i := sort.Search(len(cover), func(i int) bool { return cover[i].End >= t })
+		if i < len(cover) && cover[i].Start <= t {
+			out = append(out, ho)
+		}
+	}
+	return out
+}
+
+// ---------- parsing ----------
+
+type storyboardParser struct {
+	sb        *Storyboard
+	d         *lineDecoder // reports malformed values, shared with the beatmap decoder
+	variables map[string]string
+
+	sprite   *Sprite // element that depth 1 commands belong to
+	commands *[]Command
+	nested   *[]Command // loop or trigger that depth 2 commands belong to
+}
+
+func newStoryboardParser(sb *Storyboard, d *lineDecoder) *storyboardParser {
+	return &storyboardParser{sb: sb, d: d, variables: map[string]string{}}
+}
+
+func (p *storyboardParser) reset() {
+	p.sprite, p.commands, p.nested = nil, nil, nil
+}
+
+func (p *storyboardParser) parseVariable(line string) {
+	// $name=value
+	i := strings.Index(line, "=")
+	if !strings.HasPrefix(line, "$") || i < 0 {
+		return
+	}
+	p.variables[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+}
+
+func (p *storyboardParser) substitute(line string) string {
+	if len(p.variables) == 0 || !strings.Contains(line, "$") {
+		return line
+	}
+	// longest names first so "$ab" is not clobbered by "$a"
+	names := make([]string, 0, len(p.variables))
+	for name := range p.variables {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	for _, name := range names {
+		line = strings.ReplaceAll(line, name, p.variables[name])
+	}
+	return line
+}
+
+// parseLine handles one [Events] line with its leading indentation intact.
+// It returns false for lines that are not part of the storyboard model.
+func (p *storyboardParser) parseLine(raw string) bool {
+	depth := 0
+	for depth < len(raw) && (raw[depth] == ' ' || raw[depth] == '_') {
+		depth++
+	}
+	parts := splitCSV(raw[depth:])
+	if len(parts) == 0 {
+		return false
+	}
+
+	if depth == 0 {
+		p.reset()
+		return p.parseElement(parts)
+	}
+
+	var target *[]Command
+	switch {
+	case depth == 1 && p.sprite != nil:
+		p.nested = nil
+		switch parts[0] {
+		case "L":
+			if len(parts) < 3 {
+				p.d.warn("loop needs 3 fields, got %d", len(parts))
+				return false
+			}
+			p.sprite.Loops = append(p.sprite.Loops, CommandLoop{
+				StartTime: p.d.float(parts[1], 0),
+				Count:     p.d.int(parts[2], 1),
+			})
+			p.nested = &p.sprite.Loops[len(p.sprite.Loops)-1].Commands
+			return true
+		case "T":
+			if len(parts) < 2 {
+				p.d.warn("trigger needs a name")
+				return false
+			}
+			trigger := CommandTrigger{Name: parts[1]}
+			if len(parts) >= 3 {
+				trigger.StartTime = p.d.float(parts[2], 0)
+			}
+			if len(parts) >= 4 {
+				trigger.EndTime = p.d.float(parts[3], 0)
+			}
+			if len(parts) >= 5 {
+				trigger.Group = p.d.int(parts[4], 0)
+			}
+			p.sprite.Triggers = append(p.sprite.Triggers, trigger)
+			p.nested = &p.sprite.Triggers[len(p.sprite.Triggers)-1].Commands
+			return true
+		}
+		target = p.commands
+	case depth >= 2 && p.nested != nil:
+		target = p.nested
+	default:
+		return false
+	}
+
+	commands, ok := p.parseCommand(parts)
+	if !ok {
+		return false
+	}
+	*target = append(*target, commands...)
+	return true
+}
+
+func (p *storyboardParser) parseElement(parts []string) bool {
+	get := func(i int) string {
+		if i < len(parts) {
+			return parts[i]
+		}
+		return ""
+	}
+	switch strings.ToLower(parts[0]) {
+	case "sprite", "4":
+		if len(parts) < 6 {
+			p.d.warn("sprite needs 6 fields, got %d", len(parts))
+			return false
+		}
+		s := &Sprite{
+			Layer:  StoryboardLayer(p.enum(parts[1], layerNames, "layer")),
+			Origin: Origin(p.enum(parts[2], originNames, "origin")),
+			Path:   cleanFilename(parts[3]),
+			X:      p.d.float(parts[4], 0),
+			Y:      p.d.float(parts[5], 0),
+		}
+		p.sb.Elements = append(p.sb.Elements, s)
+		p.sprite, p.commands = s, &s.Commands
+		return true
+	case "animation", "6":
+		if len(parts) < 8 {
+			p.d.warn("animation needs 8 fields, got %d", len(parts))
+			return false
+		}
+		a := &Animation{
+			Sprite: Sprite{
+				Layer:  StoryboardLayer(p.enum(parts[1], layerNames, "layer")),
+				Origin: Origin(p.enum(parts[2], originNames, "origin")),
+				Path:   cleanFilename(parts[3]),
+				X:      p.d.float(parts[4], 0),
+				Y:      p.d.float(parts[5], 0),
+			},
+			FrameCount: p.d.int(parts[6], 1),
+			FrameDelay: p.d.float(parts[7], 0),
+			LoopType:   AnimationLoopType(p.enum(get(8), loopTypeNames, "loop type")),
+		}
+		p.sb.Elements = append(p.sb.Elements, a)
+		p.sprite, p.commands = &a.Sprite, &a.Sprite.Commands
+		return true
+	case "sample", "5":
+		if len(parts) < 4 {
+			p.d.warn("sample needs 4 fields, got %d", len(parts))
+			return false
+		}
+		p.sb.Elements = append(p.sb.Elements, &StoryboardSample{
+			Time:   p.d.float(parts[1], 0),
+			Layer:  StoryboardLayer(p.enum(parts[2], layerNames, "layer")),
+			Path:   cleanFilename(parts[3]),
+			Volume: p.d.int(get(4), 100),
+		})
+		return true
+	}
+	return false
+}
+
+// parseCommand expands stable's shorthand: extra values chain further commands of the same duration.
+func (p *storyboardParser) parseCommand(parts []string) ([]Command, bool) {
+	typ := -1
+	for i, name := range commandNames {
+		if parts[0] == name {
+			typ = i
+			break
+		}
+	}
+	if typ < 0 {
+		return nil, false
+	}
+	if len(parts) < 5 {
+		p.d.warn("command needs 5 fields, got %d", len(parts))
+		return nil, false
+	}
+	easing := p.d.int(parts[1], 0)
+	start := p.d.float(parts[2], 0)
+	end := p.d.float(parts[3], start)
+
+	if CommandType(typ) == CommandParameter {
+		return []Command{{
+			Type: CommandParameter, Easing: easing, StartTime: start, EndTime: end, Parameter: parts[4],
+		}}, true
+	}
+
+	n := commandValueCounts[typ]
+	values := make([]float64, 0, len(parts)-4)
+	for _, v := range parts[4:] {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			p.d.warn("invalid number %q", v)
+			return nil, false
+		}
+		values = append(values, f)
+	}
+	if len(values) < n {
+		p.d.warn("command needs %d values, got %d", n, len(values))
+		return nil, false
+	}
+	if len(values) < 2*n {
+		return []Command{{
+			Type: CommandType(typ), Easing: easing, StartTime: start, EndTime: end,
+			Start: values[:n], End: values[:n],
+		}}, true
+	}
+
+	duration := end - start
+	var out []Command
+	for k := 0; (k+2)*n <= len(values); k++ {
+		out = append(out, Command{
+			Type:      CommandType(typ),
+			Easing:    easing,
+			StartTime: start + float64(k)*duration,
+			EndTime:   end + float64(k)*duration,
+			Start:     values[k*n : (k+1)*n],
+			End:       values[(k+1)*n : (k+2)*n],
+		})
+	}
+	return out, true
+}
+
+// enum accepts either the name or the numeric value, defaulting to 0.
+func (p *storyboardParser) enum(s string, names []string, what string) int {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			return i
+		}
+	}
+	if v, err := strconv.Atoi(s); err == nil && v >= 0 && v < len(names) {
+		return v
+	}
+	p.d.warn("unknown %s %q", what, s)
+	return 0
+}
+
+// ---------- encoding ----------
+
+func (e *encoder) encodeStoryboard(sb *Storyboard) {
+	for _, el := range sb.Elements {
+		switch el := el.(type) {
+		case *Sprite:
+			e.line("Sprite,%s,%s,\"%s\",%s,%s",
+				layerNames[el.Layer], originNames[el.Origin], el.Path, formatFloat(el.X), formatFloat(el.Y))
+			e.encodeSpriteCommands(el)
+		case *Animation:
+			e.line("Animation,%s,%s,\"%s\",%s,%s,%d,%s,%s",
+				layerNames[el.Layer], originNames[el.Origin], el.Path, formatFloat(el.X), formatFloat(el.Y),
+				el.FrameCount, formatFloat(el.FrameDelay), loopTypeNames[el.LoopType])
+			e.encodeSpriteCommands(&el.Sprite)
+		case *StoryboardSample:
+			e.line("Sample,%s,%d,\"%s\",%d", formatFloat(el.Time), el.Layer, el.Path, el.Volume)
+		}
+	}
+}
+
+func (e *encoder) encodeSpriteCommands(s *Sprite) {
+	for _, c := range s.Commands {
+		e.line(" %s", formatCommand(c))
+	}
+	for _, l := range s.Loops {
+		e.line(" L,%s,%d", formatFloat(l.StartTime), l.Count)
+		for _, c := range l.Commands {
+			e.line("  %s", formatCommand(c))
+		}
+	}
+	for _, t := range s.Triggers {
+		e.line(" T,%s,%s,%s,%d", t.Name, formatFloat(t.StartTime), formatFloat(t.EndTime), t.Group)
+		for _, c := range t.Commands {
+			e.line("  %s", formatCommand(c))
+		}
+	}
+}
+
+func formatCommand(c Command) string {
+	s := fmt.Sprintf("%s,%d,%s,%s", commandNames[c.Type], c.Easing, formatFloat(c.StartTime), formatFloat(c.EndTime))
+	if c.Type == CommandParameter {
+		return s + "," + c.Parameter
+	}
+	for _, v := range c.Start {
+		s += "," + formatFloat(v)
+	}
+	for _, v := range c.End {
+		s += "," + formatFloat(v)
+	}
+	return s
+}
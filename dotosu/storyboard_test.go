@@ -0,0 +1,137 @@
+package dotosu
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleStoryboard = `[Variables]
+$bg="sb/black.png"
+$bgLayer=Overlay
+
+[Events]
+//Storyboard Layer 0 (Background)
+Sprite,Background,Centre,$bg,320,240
+ F,0,0,1000,1
+Sprite,$bgLayer,Centre,$bg,320,240
+ F,0,2000,2500,0,1,0
+ L,3000,2
+_ F,0,0,500,0.8
+Sprite,Overlay,Centre,"sb/dim.png",320,240
+ F,0,9000,9500,0.1
+`
+
+func TestDecodeStoryboard(t *testing.T) {
+	sb, err := DecodeStoryboard(strings.NewReader(sampleStoryboard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sprites := sb.Sprites()
+	if len(sprites) != 3 {
+		t.Fatalf("got %d sprites, want 3", len(sprites))
+	}
+	overlay := sprites[1]
+	if overlay.Layer != LayerOverlay || overlay.Path != "sb/black.png" {
+		t.Fatalf("variables not substituted: %+v", overlay)
+	}
+	// shorthand chains into two fades of the same duration
+	if len(overlay.Commands) != 2 || overlay.Commands[1].StartTime != 2500 || overlay.Commands[1].End[0] != 0 {
+		t.Fatalf("fade chain: %+v", overlay.Commands)
+	}
+	if len(overlay.Loops) != 1 || len(overlay.Loops[0].Commands) != 1 {
+		t.Fatalf("loop: %+v", overlay.Loops)
+	}
+	if start, end, _ := overlay.ActiveRange(); start != 2000 || end != 4000 {
+		t.Errorf("active range: %v-%v", start, end)
+	}
+
+	cover := sb.PlayfieldCover(0.5, FullscreenImages)
+	if len(cover) != 1 || cover[0] != (BreakPeriod{Start: 2000, End: 4000}) {
+		t.Errorf("cover: %+v", cover)
+	}
+
+	b := &Beatmap{HitObjects: []HitObject{Circle{BaseHO{Time: 1000}}}, Storyboard: *sb}
+	if b.HidesPlayfield(0.5, FullscreenImages) {
+		t.Error("object at 1000 is not covered")
+	}
+	b.HitObjects = append(b.HitObjects, Circle{BaseHO{Time: 3500}})
+	if !b.HidesPlayfield(0.5, FullscreenImages) {
+		t.Error("object at 3500 is covered")
+	}
+}
+
+func TestCoversPlayfield(t *testing.T) {
+	const overlay = `[Events]
+Sprite,Overlay,Centre,"sb/box.png",320,240
+ F,0,0,1000,1
+Sprite,Overlay,TopLeft,"sb/box.png",0,0
+ S,0,2000,3000,0.5,2
+ F,0,2000,3000,1
+Sprite,Overlay,Centre,"sb/box.png",320,240
+ V,0,4000,5000,1,0.5
+ F,0,4000,5000,1
+Sprite,Overlay,TopLeft,"sb/box.png",0,0
+ M,0,6000,7000,0,0,60,40
+ F,0,6000,7000,1
+`
+	sb, err := DecodeStoryboard(strings.NewReader(overlay))
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := func(string) (float64, float64, bool) { return 640, 480, true }
+	// only the first one and the one that moves within its margin keep the playfield hidden;
+	// the others shrink to half of it at some point
+	want := []BreakPeriod{{Start: 0, End: 1000}, {Start: 6000, End: 7000}}
+	if got := sb.PlayfieldCover(0.5, size); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("cover: %+v", got)
+	}
+
+	small := func(string) (float64, float64, bool) { return 32, 32, true }
+	if got := sb.PlayfieldCover(0.5, small); len(got) != 0 {
+		t.Errorf("small sprites cover: %+v", got)
+	}
+}
+
+func TestStoryboardDiagnostics(t *testing.T) {
+	const broken = `[Events]
+Sprite,Overlay,Centre,"sb/a.png",x,240
+ F,0,0,1000,half
+Sprite,Overlay,Centre
+Sprite,Sideways,Centre,"sb/b.png",320,240
+ M,0,1000,2000,320
+`
+	sb, err := DecodeStoryboard(strings.NewReader(broken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Diagnostic{
+		{Section: "Events", Line: 2, Raw: `Sprite,Overlay,Centre,"sb/a.png",x,240`, Reason: `invalid number "x"`},
+		{Section: "Events", Line: 3, Raw: "F,0,0,1000,half", Reason: `invalid number "half"`},
+		{Section: "Events", Line: 4, Raw: "Sprite,Overlay,Centre", Reason: "sprite needs 6 fields, got 3"},
+		{Section: "Events", Line: 5, Raw: `Sprite,Sideways,Centre,"sb/b.png",320,240`, Reason: `unknown layer "Sideways"`},
+		{Section: "Events", Line: 6, Raw: "M,0,1000,2000,320", Reason: "command needs 2 values, got 1"},
+	}
+	if len(sb.Warnings) != len(want) {
+		t.Fatalf("warnings: %v", sb.Warnings)
+	}
+	for i := range want {
+		if sb.Warnings[i] != want[i] {
+			t.Errorf("warning %d: got %v, want %v", i, sb.Warnings[i], want[i])
+		}
+	}
+	if _, err := DecodeStoryboardWithOptions(strings.NewReader(broken), DecodeOptions{Strict: true}); err == nil {
+		t.Error("strict decode accepted a broken storyboard")
+	}
+
+	// a beatmap's own storyboard reports to the beatmap
+	b, err := Decode(strings.NewReader("osu file format v14\n\n" + broken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Warnings) != len(want) || b.Warnings[0].Line != 4 {
+		t.Errorf("beatmap warnings: %v", b.Warnings)
+	}
+	if _, err := DecodeWithOptions(strings.NewReader("osu file format v14\n\n"+broken), DecodeOptions{Strict: true}); err == nil {
+		t.Error("strict decode accepted a broken storyboard")
+	}
+}
@@ -1,11 +1,12 @@
 package main
 
 import (
-	"archive/zip"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"ppv3/dotosu"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -23,20 +24,20 @@ func rateLimited() time.Duration {
 	return time.Minute
 }
 
-func DownloadBeatmapset(set Beatmapset) (map[string][]byte, error) {
+func DownloadBeatmapset(set Beatmapset) ([]byte, error) {
 	done := GetToken()
 	defer done()
-	var bytes []byte
+	var data []byte
 	for {
 		var err error
-		bytes, err = DownloadBeatmapsetBytes(set.ID)
+		data, err = DownloadBeatmapsetBytes(set.ID)
 		if err != nil && strings.Contains(err.Error(), "connection refused") {
 			cooldown := rateLimited()
 			fmt.Printf("\n\n\n\n\nconnection refused, %s\n", cooldown)
 			time.Sleep(max(time.Minute, cooldown))
 			continue
 		}
-		if strings.Contains(string(bytes), "Slow down, play more.") {
+		if strings.Contains(string(data), "Slow down, play more.") {
 			cooldown := rateLimited()
 			fmt.Printf("\n\n\n\n\nSlow down, play more. %s\n", cooldown)
 			time.Sleep(max(time.Minute, cooldown))
@@ -51,56 +52,27 @@ func DownloadBeatmapset(set Beatmapset) (map[string][]byte, error) {
 			continue
 		}
 	}
-	// Treat the .osz file as a ZIP file by reading it using zip.NewReader
-	zipReader, err := zip.NewReader(strings.NewReader(string(bytes)), int64(len(bytes)))
+	osz, err := dotosu.OpenOsz(bytes.NewReader(data), int64(len(data)))
 	if err != nil {
 		if strings.Contains(err.Error(), "not a valid zip file") {
-			bytes, _ := json.Marshal(set)
-			Fail("_not_zip", set.ID, err.Error()+"\n\n"+string(bytes))
-			return map[string][]byte{}, nil
+			setJSON, _ := json.Marshal(set)
+			Fail("_not_zip", set.ID, err.Error()+"\n\n"+string(setJSON))
+			return nil, nil
 		}
 		return nil, fmt.Errorf("error opening osz (zip) id:%d err: %v", set.ID, err)
 	}
-
-	// Create a map to hold the .osu files
-	osuFiles := make(map[string][]byte)
-
-	// Iterate over each file in the zip archive
-	for _, file := range zipReader.File {
-		if !strings.HasSuffix(file.Name, ".osu") {
-			continue
+	for _, difficulty := range osz.Difficulties {
+		if difficulty.Err != nil {
+			Fail("_broken_files", set.ID, difficulty.Path+"\n\n"+difficulty.Err.Error())
 		}
-		if file.FileInfo().IsDir() {
-			Fail("_broken_files", set.ID, file.Name)
-			continue
-		}
-		if strings.Contains(file.Name, "/") || strings.Contains(file.Name, "\\") {
-			Fail("_broken_files", set.ID, file.Name)
-			continue
-		}
-		// Open the .osu file inside the zip archive
-		fileReader, err := file.Open()
-		if err != nil {
-			return nil, fmt.Errorf("error opening .osu file %s: %v", file.Name, err)
-		}
-		defer fileReader.Close()
-
-		// Read the contents of the .osu file
-		fileContents, err := io.ReadAll(fileReader)
-		if err != nil {
-			return nil, fmt.Errorf("error reading .osu file %s: %v", file.Name, err)
-		}
-
-		// Store the file contents in the map
-		osuFiles[file.Name] = fileContents
 	}
 
-	if len(osuFiles) == 0 {
+	if len(osz.Difficulties) == 0 {
 		PanicF("no .osu files found in the beatmap")
 	}
 
-	// Return the map of .osu files
-	return osuFiles, nil
+	// The archive is kept as is, OpenSet reads it directly
+	return data, nil
 }
 
 func DownloadBeatmapsetBytes(beatmapsetId int) ([]byte, error) {
@@ -1,18 +1,24 @@
 package main
 
+import "slices"
+
 type sample struct {
-	SkillVector [skillCount]float64
+	SkillVector []float64 // indexed like ActiveSkills
 	PPIter      PPIter
 }
 
-func scaleSkills(skills [skillCount]float64, factor float64) [skillCount]float64 {
-	for i := range skillCount {
-		skills[i] = min(1e30, max(1, skills[i]*factor))
+func scaleSkills(active []SkillDef, skills []float64, factor float64) []float64 {
+	scaled := make([]float64, len(skills))
+	for i, def := range active {
+		scaled[i] = min(def.Max, max(def.Min, skills[i]*factor))
 	}
-	return skills
+	return scaled
 }
 
+// GradientDescent fits the active skills, see ActiveSkills, to the lowest pp that fn still
+// finds likely enough.
 func GradientDescent(
+	active []SkillDef,
 	fn func(Skills) PPIter,
 ) PPIter {
 	scaleSample := func(x sample) sample {
@@ -29,19 +35,20 @@ func GradientDescent(
 			if overExists && overSample.PPIter.ProbResult < TargetProbability+1e-5 {
 				return overSample
 			}
-			var nextVector [skillCount]float64
+			var nextVector []float64
 			if !underExists {
-				nextVector = scaleSkills(overSample.SkillVector, 0.01)
+				nextVector = scaleSkills(active, overSample.SkillVector, 0.01)
 			} else if !overExists {
-				nextVector = scaleSkills(underSample.SkillVector, 100)
+				nextVector = scaleSkills(active, underSample.SkillVector, 100)
 			} else {
-				for i := range skillCount {
+				nextVector = make([]float64, len(active))
+				for i := range active {
 					nextVector[i] = (underSample.SkillVector[i] + overSample.SkillVector[i]) / 2
 				}
 			}
 			nextSample := sample{
 				SkillVector: nextVector,
-				PPIter:      fn(VectorToSkills(nextVector)),
+				PPIter:      fn(VectorToSkills(active, nextVector)),
 			}
 			if nextSample.PPIter.ProbResult < TargetProbability {
 				underExists = true
@@ -56,10 +63,11 @@ func GradientDescent(
 
 	var ret sample
 	{
-		for i := range skillCount {
-			ret.SkillVector[i] = 300
+		ret.SkillVector = make([]float64, len(active))
+		for i, def := range active {
+			ret.SkillVector[i] = def.Default
 		}
-		ret.PPIter = fn(VectorToSkills(ret.SkillVector))
+		ret.PPIter = fn(VectorToSkills(active, ret.SkillVector))
 		ret = scaleSample(ret)
 	}
 
@@ -74,13 +82,13 @@ func GradientDescent(
 			improved = false
 			for _, sign := range []float64{-1, 1} {
 				lastPP := ret.PPIter.PP
-				for i := range skillCount {
+				for i, def := range active {
 					newSample := sample{
-						SkillVector: ret.SkillVector,
+						SkillVector: slices.Clone(ret.SkillVector),
 					}
-					newSample.SkillVector[i] = max(1, newSample.SkillVector[i]+delta*sign)
+					newSample.SkillVector[i] = min(def.Max, max(def.Min, newSample.SkillVector[i]+delta*sign))
 
-					newSample.PPIter = fn(VectorToSkills(newSample.SkillVector))
+					newSample.PPIter = fn(VectorToSkills(active, newSample.SkillVector))
 					newSample = scaleSample(newSample)
 
 					if newSample.PPIter.PP < ret.PPIter.PP {
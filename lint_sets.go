@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"ppv3/dotosu"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// QuarantineRankedSets lints every beatmap in ../_ranked_sets and reports the ones with
+// error findings to ../_lint/<beatmap id>. It returns the IDs of the sets holding them, which
+// the pp run skips; sets downloaded during the run are only linted by the next one.
+func QuarantineRankedSets() (quarantined map[int]bool) {
+	quarantined = map[int]bool{}
+	entries, err := os.ReadDir("../_ranked_sets")
+	if os.IsNotExist(err) {
+		return quarantined
+	}
+	if err != nil {
+		panic(err)
+	}
+	if err := os.MkdirAll("../_lint", 0o755); err != nil {
+		panic(err)
+	}
+	rules := slices.Clone(dotosu.DefaultLintRules)
+	for i, rule := range rules {
+		if rule.Name == "outside-playfield" {
+			rules[i] = dotosu.OutsidePlayfieldRule(sliderPathPosition)
+		}
+	}
+	seen := map[int]bool{}
+	for _, entry := range entries {
+		// sets are either raw .osz archives or folders from older downloads
+		setID, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".osz"))
+		if err != nil || seen[setID] {
+			continue
+		}
+		seen[setID] = true
+		set, _, err := OpenSet(setID)
+		if err != nil {
+			fmt.Printf("lint: set %d: %s\n", setID, err.Error())
+		}
+		storyboard, imageSize, closeSet := setStoryboard(setID)
+		setRules := append(slices.Clone(rules), dotosu.HiddenPlayfieldRule(storyboard, imageSize))
+		for _, beatmap := range set {
+			findings := beatmap.Lint(setRules...)
+			if dotosu.MaxSeverity(findings) < dotosu.SeverityError {
+				continue
+			}
+			quarantined[setID] = true
+			Fail("_lint", beatmap.Metadata.BeatmapID, dotosu.FormatFindings(findings))
+		}
+		closeSet()
+	}
+	return quarantined
+}
+
+// setStoryboard reads the .osb of a set, nil without one, and the sizes of its images for the
+// hidden-playfield rule. The ImageSize reads from the set until closeSet is called.
+func setStoryboard(setID int) (storyboard *dotosu.Storyboard, size dotosu.ImageSize, closeSet func()) {
+	if file, err := os.Open(oszPath(setID)); err == nil {
+		info, err := file.Stat()
+		if err != nil {
+			panic(err)
+		}
+		osz, err := dotosu.OpenOsz(file, info.Size())
+		if err != nil {
+			file.Close()
+			return nil, dotosu.FullscreenImages, func() {}
+		}
+		return osz.Storyboard, osz.ImageSize, func() { file.Close() }
+	}
+	dir := fmt.Sprintf("../_ranked_sets/%d", setID)
+	size = func(name string) (float64, float64, bool) {
+		file, err := os.Open(filepath.Join(dir, filepath.FromSlash(strings.ReplaceAll(name, "\\", "/"))))
+		if err != nil {
+			return 0, 0, false
+		}
+		defer file.Close()
+		config, _, err := image.DecodeConfig(file)
+		if err != nil {
+			return 0, 0, false
+		}
+		return float64(config.Width), float64(config.Height), true
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if !strings.EqualFold(filepath.Ext(entry.Name()), ".osb") {
+			continue
+		}
+		storyboard, err := dotosu.DecodeStoryboardFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			fmt.Printf("lint: set %d: %s\n", setID, err.Error())
+			break
+		}
+		return storyboard, size, func() {}
+	}
+	return nil, size, func() {}
+}
+
+func sliderPathPosition(slider dotosu.Slider, progress float64) (x, y float64) {
+	pos := NewSliderCurve(slider).PositionAt(progress)
+	return pos.X, pos.Y
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"cmp"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"io/fs"
@@ -17,9 +18,20 @@ import (
 )
 
 func main() {
+	skillOverrides := flag.String("skills", "", `JSON file of skill overrides, like {"Reading.Blinds": {"Disabled": true}}`)
+	flag.Parse()
+	if *skillOverrides != "" {
+		registry, err := LoadSkillOverrides(*skillOverrides)
+		if err != nil {
+			panic(err)
+		}
+		SkillRegistry = registry
+	}
+
 	users := []int{10077431, 7562902, 17592067}
+	quarantined := QuarantineRankedSets()
 	for _, userId := range users {
-		pprecalc, err := EvalUserScores(userId)
+		pprecalc, err := EvalUserScores(userId, quarantined)
 		if err != nil {
 			panic(err)
 		}
@@ -40,25 +52,35 @@ func main() {
 type Modifiers struct {
 	Lazer bool
 
-	Rate float64
+	Rate          float64 // clock rate, the initial one when it changes during the map
+	FinalRate     float64 // Wind Up/Down: rate the ramp ends at, 0 when the rate is constant
+	AdaptiveSpeed bool
 
 	Hardrock bool
 	Easy     bool
+	Mirror   MirrorAxes
 
 	Hidden     bool
 	Flashlight bool
 
 	NoFail  bool
 	SpunOut bool
+
+	// lazer only
+	DifficultyAdjust   DifficultyAdjust
+	ClassicSliderHeads bool // Classic: slider heads judged as hit or miss, like stable
+	StrictTracking     bool
+	Traceable          bool
+	Blinds             bool
 }
 
 func OpenBeatmap(id int) (*Beatmap, *dotosu.Beatmap) {
 	info := LoadBeatmap(id)
 
 	{
-		dir := fmt.Sprintf("../_ranked_sets/%d", info.BeatmapsetID)
-		_, err := os.Stat(dir)
-		if err != nil {
+		_, oszErr := os.Stat(oszPath(info.BeatmapsetID))
+		_, dirErr := os.Stat(fmt.Sprintf("../_ranked_sets/%d", info.BeatmapsetID))
+		if oszErr != nil && dirErr != nil {
 			DownloadSets([]Beatmapset{info.Beatmapset})
 		}
 	}
@@ -76,7 +98,16 @@ func OpenBeatmap(id int) (*Beatmap, *dotosu.Beatmap) {
 	panic(fmt.Sprintf("not found %d %d %v %v", id, info.BeatmapsetID, allFiles, ids))
 }
 
+func oszPath(setID int) string {
+	return fmt.Sprintf("../_ranked_sets/%d.osz", setID)
+}
+
+// OpenSet reads a set from its downloaded .osz, or from the extracted folder of older downloads.
 func OpenSet(id int) ([]*dotosu.Beatmap, []string, error) {
+	if file, err := os.Open(oszPath(id)); err == nil {
+		defer file.Close()
+		return openOsz(file)
+	}
 	var allFiles []string
 	dir := fmt.Sprintf("../_ranked_sets/%d", id)
 	info, err := os.Stat(dir)
@@ -131,6 +162,31 @@ func OpenSet(id int) ([]*dotosu.Beatmap, []string, error) {
 	return beatmaps, allFiles, nil
 }
 
+func openOsz(file *os.File) ([]*dotosu.Beatmap, []string, error) {
+	var allFiles []string
+	info, err := file.Stat()
+	if err != nil {
+		return nil, allFiles, err
+	}
+	osz, err := dotosu.OpenOsz(file, info.Size())
+	if err != nil {
+		return nil, allFiles, fmt.Errorf("%s: %w", file.Name(), err)
+	}
+	for _, difficulty := range osz.Difficulties {
+		allFiles = append(allFiles, difficulty.Path)
+	}
+	for _, asset := range osz.Assets {
+		allFiles = append(allFiles, asset.Path)
+	}
+	beatmaps := osz.Beatmaps()
+	for _, difficulty := range osz.Difficulties {
+		if difficulty.Err != nil {
+			return beatmaps, allFiles, fmt.Errorf("decoded %d/%d .osu files; first failure %s: %w", len(beatmaps), len(osz.Difficulties), difficulty.Path, difficulty.Err)
+		}
+	}
+	return beatmaps, allFiles, nil
+}
+
 func DownloadSets(rankedSets []Beatmapset) {
 	wg := sync.WaitGroup{}
 	counter := atomic.Uint32{}
@@ -142,6 +198,10 @@ func DownloadSets(rankedSets []Beatmapset) {
 			Fail("_skips", set.ID, string(bytes))
 			continue
 		}
+		if _, err := os.Stat(oszPath(set.ID)); err == nil {
+			fmt.Printf("%d already downloaded (%s)\n", set.ID, set.Title)
+			continue
+		}
 		{
 			file, err := os.Open(fmt.Sprintf("../_ranked_sets/%d", set.ID))
 			if err == nil {
@@ -170,27 +230,19 @@ func DownloadSets(rankedSets []Beatmapset) {
 		Run(func() {
 			defer wg.Done()
 			total.Add(1)
-			files, err := DownloadBeatmapset(set)
+			data, err := DownloadBeatmapset(set)
 			if err != nil {
 				PanicF("DownloadBeatmapset failed id = %d, err = %s", set.ID, err.Error())
 			}
-			err = os.Mkdir(fmt.Sprintf("../_ranked_sets/%d", set.ID), 0777)
-			if err != nil && !strings.Contains(err.Error(), "file exists") {
-				PanicF("Mkdir failed id = %d, err = %s", set.ID, err.Error())
+			if data == nil {
+				return
 			}
 			counter.Add(1)
 			fmt.Printf("%d downloaded (%s)\n", set.ID, set.Title)
 			fmt.Printf("%d/%d\n\n", counter.Load(), total.Load())
-			for fileName, data := range files {
-				file, err := os.Create(fmt.Sprintf("../_ranked_sets/%d/%s", set.ID, fileName))
-				if err != nil {
-					PanicF("os.Create failed id = %d, err = %s", set.ID, err.Error())
-				}
-				defer file.Close()
-				_, err = file.Write(data)
-				if err != nil {
-					PanicF("file.Write failed id = %d, err = %s", set.ID, err.Error())
-				}
+			err = os.WriteFile(oszPath(set.ID), data, 0666)
+			if err != nil {
+				PanicF("os.WriteFile failed id = %d, err = %s", set.ID, err.Error())
 			}
 		})
 	}
@@ -3,8 +3,9 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"math"
 	"ppv3/dotosu"
+	"slices"
+	"sort"
 )
 
 type Action struct {
@@ -15,7 +16,25 @@ type Action struct {
 	Circle     bool
 	SliderEnd  bool
 	SliderTick bool
-	Spinner    bool
+	SliderLast bool // last nested object of a slider, closes its stable judgement
+
+	// slider nested objects: how the ball moved since the previous action of the slider
+	FollowDistance float64 // path length covered
+	FollowTurning  float64 // total turning angle, radians
+	Spinner        bool
+	Rate           float64 // clock rate at the action, see MapConstants
+
+	// stamina: decaying load of the actions before this one
+	TapLoad    float64
+	StreamLoad float64 // the part of TapLoad from the current stream
+	JumpLoad   float64
+
+	// rhythm: the burst this click is part of, see SegmentBursts
+	BurstLength     int
+	BurstPos        int  // index of the click in its burst
+	PrevBurstLength int  // length of the burst before this click's burst
+	Snap            int  // snap divisor of the click, 0 when unsnapped
+	SnapChange      bool // the click moves between the 1/4 and the 1/3 grid, or off them
 
 	LastClicks []TimePos
 	LastAims   []TimePos
@@ -26,33 +45,35 @@ func ConvertBeatmapToActions(
 	beatmap *dotosu.Beatmap,
 ) ([]*Action, error) {
 	actions := make([]*Action, 0, len(beatmap.HitObjects))
+	stackObjects := make([]StackObject, 0, len(beatmap.HitObjects))
 
-	timingPoints := beatmap.TimingPoints
-	timingPointIndex := 0
-	var lastRedLine *dotosu.TimingPoint
-	var lastGreenLine *dotosu.TimingPoint
+	transform := mapConstants.Mods.PlayfieldTransform()
+	controlPoints := beatmap.ControlPoints()
 objectLoop:
 	for _, object := range beatmap.HitObjects {
-		for timingPointIndex < len(timingPoints) && (lastRedLine == nil || timingPoints[timingPointIndex].Time <= object.StartTime()) {
-			timingPoint := timingPoints[timingPointIndex]
-			timingPointIndex++
-
-			if timingPoint.TimingChange {
-				lastRedLine = &timingPoint
-				lastGreenLine = nil
-			} else {
-				lastGreenLine = &timingPoint
-			}
-		}
-		switch object := object.(type) {
+		firstAction := len(actions)
+		switch object := transform.ApplyToObject(object).(type) {
 		case dotosu.Circle:
+			pos := Vec{
+				X: float64(object.PosXY.X),
+				Y: float64(object.PosXY.Y),
+			}
+			stackObjects = append(
+				stackObjects,
+				StackObject{
+					Kind:        dotosu.KindCircle,
+					StartTime:   float64(object.Time),
+					EndTime:     float64(object.Time),
+					Pos:         pos,
+					EndPos:      pos,
+					PathEndPos:  pos,
+					FirstAction: firstAction,
+				},
+			)
 			actions = append(
 				actions,
 				&Action{
-					Pos: Vec{
-						X: float64(object.PosXY.X),
-						Y: float64(object.PosXY.Y),
-					},
+					Pos:       pos,
 					Time:      float64(object.Time),
 					Radius:    mapConstants.CircleRadius,
 					Clickable: true,
@@ -60,99 +81,104 @@ objectLoop:
 				},
 			)
 		case dotosu.Slider:
-			beatLength := lastRedLine.BeatLength
-			var sv float64
-			if lastGreenLine != nil {
-				sv = max(0.1, lastGreenLine.SliderVelocityMultiplier)
-			} else {
-				sv = 1
-			}
-
-			samples := ApproximateSliderPath(object)
+			curve := NewSliderCurve(object)
 
+			head := Vec{
+				X: float64(object.PosXY.X),
+				Y: float64(object.PosXY.Y),
+			}
 			actions = append(
 				actions,
 				&Action{
-					Pos: Vec{
-						X: float64(object.PosXY.X),
-						Y: float64(object.PosXY.Y),
-					},
+					Pos:       head,
 					Time:      float64(object.Time),
 					Radius:    mapConstants.CircleRadius,
 					Clickable: true,
 				},
 			)
-			visualLength := object.Length
-			timeLength := visualLength / (beatmap.Difficulty.SliderMultiplier * 100 * sv) * beatLength
-
-			ticksFloat := timeLength / beatLength * beatmap.Difficulty.SliderTickRate
-			ticks := max(0, int(math.Floor((timeLength-min(36, timeLength/2))/beatLength*beatmap.Difficulty.SliderTickRate)))
-			tickLength := visualLength / ticksFloat
-
-			tickTime := beatLength / beatmap.Difficulty.SliderTickRate
-
-			for i := range object.Slides {
-				for j := range ticks {
-					var progress float64
-					var time float64
-					if i%2 == 0 {
-						time = float64(object.Time) +
-							float64(i)*timeLength +
-							float64(j+1)*tickTime
-						progress = float64(j+1) * tickLength
-					} else {
-						time = float64(object.Time) +
-							float64(i+1)*timeLength +
-							float64(j-ticks)*tickTime
-						progress = float64(ticks-j) * tickLength
-					}
-					actions = append(
-						actions,
-						&Action{
-							Pos:        GetSliderPosition(samples, progress),
-							Time:       time,
-							Radius:     mapConstants.CircleRadius * 2.4,
-							Clickable:  false,
-							SliderTick: true,
-						},
-					)
-				}
-				repeatTime := float64(object.Time) +
-					float64(i+1)*timeLength
-				var sliderend Vec
-				if i == object.Slides-1 {
-					effectiveLength := timeLength - min(36, timeLength/2)
-					repeatTime -= min(36, timeLength/2)
-					var progress float64
-					if i%2 == 0 {
-						progress = effectiveLength / timeLength * visualLength
-					} else {
-						progress = (1 - effectiveLength/timeLength) * visualLength
-					}
-					sliderend = GetSliderPosition(samples, progress)
-				} else {
-					if i%2 == 0 {
-						sliderend = GetSliderPosition(samples, visualLength)
-					} else {
-						sliderend = Vec{
-							X: float64(object.PosXY.X),
-							Y: float64(object.PosXY.Y),
-						}
-					}
+
+			// lazer times the slider by the path distance, which is the declared length when there is one
+			timing := beatmap.SliderTiming(controlPoints, object)
+			spanDuration := curve.Length() / timing.Velocity
+			events := dotosu.GenerateSliderEvents(
+				float64(object.Time),
+				spanDuration,
+				timing.Velocity,
+				timing.TickDistance,
+				curve.Length(),
+				object.Slides,
+			)
+
+			pathEnd := curve.PositionAt(1)
+			end := pathEnd
+			if object.Slides%2 == 0 {
+				end = head
+			}
+			stackObjects = append(
+				stackObjects,
+				StackObject{
+					Kind:        dotosu.KindSlider,
+					StartTime:   float64(object.Time),
+					EndTime:     float64(object.Time) + float64(max(1, object.Slides))*spanDuration,
+					Pos:         head,
+					EndPos:      end,
+					PathEndPos:  pathEnd,
+					FirstAction: firstAction,
+				},
+			)
+
+			nested := make([]*Action, 0, len(events))
+			for _, event := range events {
+				switch event.Type {
+				case dotosu.SliderEventHead, dotosu.SliderEventTail:
+					// the head is the click above, the tail is judged at the legacy last tick
+					continue
 				}
-				actions = append(
-					actions,
+				nested = append(
+					nested,
 					&Action{
-						Pos:        sliderend,
-						Time:       repeatTime,
+						Pos:        curve.PositionAt(event.PathProgress),
+						Time:       event.Time,
 						Radius:     mapConstants.CircleRadius * 2.4,
 						Clickable:  false,
-						SliderEnd:  i == object.Slides-1,
-						SliderTick: i != object.Slides-1,
+						SliderEnd:  event.Type == dotosu.SliderEventLegacyLastTick,
+						SliderTick: event.Type != dotosu.SliderEventLegacyLastTick,
 					},
 				)
 			}
+			// a slider without duration ends where it starts, so it is judged like a circle
+			nested = slices.DeleteFunc(nested, func(action *Action) bool { return action.Time <= float64(object.Time) })
+			if len(nested) == 0 {
+				actions[firstAction].Circle = true
+				break
+			}
+			// like lazer's nested objects; a tick can come after the legacy last tick
+			sort.SliceStable(nested, func(i, j int) bool { return nested[i].Time < nested[j].Time })
+			nested[len(nested)-1].SliderLast = true
+			prevTime := float64(object.Time)
+			for _, action := range nested {
+				action.FollowDistance, action.FollowTurning = curve.FollowBetween(
+					spanDuration,
+					object.Slides,
+					prevTime-float64(object.Time),
+					action.Time-float64(object.Time),
+				)
+				prevTime = action.Time
+			}
+			actions = append(actions, nested...)
 		case dotosu.Spinner:
+			stackObjects = append(
+				stackObjects,
+				StackObject{
+					Kind:        dotosu.KindSpinner,
+					StartTime:   float64(object.Time),
+					EndTime:     float64(object.EndTime),
+					Pos:         CenterPos,
+					EndPos:      CenterPos,
+					PathEndPos:  CenterPos,
+					FirstAction: firstAction,
+				},
+			)
 			if mapConstants.Mods.SpunOut {
 				continue objectLoop
 			}
@@ -169,7 +195,16 @@ objectLoop:
 		default:
 			panic("unexpected")
 		}
+		stackObjects[len(stackObjects)-1].LastAction = len(actions)
 	}
+	ApplyStacking(mapConstants, beatmap, stackObjects, actions)
+
+	for _, action := range actions {
+		if action.Clickable {
+			action.Snap = controlPoints.SnapDivisorAt(action.Time)
+		}
+	}
+
 	for i := 1; i < len(actions); i++ {
 		if actions[i-1].Time >= actions[i].Time {
 			a, _ := json.Marshal(actions[i-1])
@@ -177,8 +212,16 @@ objectLoop:
 			return nil, fmt.Errorf("actions too close at times:\n%v\n%v\nbeatmapId=%d\n", string(a), string(b), beatmap.Metadata.BeatmapID)
 		}
 	}
-	for i := range actions {
-		actions[i].Time /= mapConstants.Mods.Rate
+	if len(stackObjects) > 0 {
+		lastObjectEnd := 0.0
+		for _, object := range stackObjects {
+			lastObjectEnd = max(lastObjectEnd, object.EndTime)
+		}
+		rate := mapConstants.Mods.RateCurve(stackObjects[0].StartTime, lastObjectEnd)
+		for i := range actions {
+			actions[i].Rate = rate.RateAt(actions[i].Time)
+			actions[i].Time = rate.RealTime(actions[i].Time)
+		}
 	}
 
 	PrecalculateActionStuff(actions)
@@ -217,4 +260,7 @@ func PrecalculateActionStuff(
 		}
 		aims = append(aims, timePos)
 	}
+
+	PrecalculateStamina(actions)
+	PrecalculateRhythm(actions)
 }
@@ -2,14 +2,21 @@ package main
 
 import "ppv3/dotosu"
 
+// MapConstants holds what the difficulty settings and mods make of a map. The durations are in
+// map time; divide them by Action.Rate for the time the player has, as the rate can change.
 type MapConstants struct {
 	Mods         Modifiers
 	CircleRadius float64
-	ApproachRate float64
+	ApproachRate float64 // as played at the initial rate
 	Preempt      float64
 	Window300    float64
 	Window100    float64
 	Window50     float64
+
+	// lazer OsuHitObject/OsuModHidden fade timings
+	FadeIn          float64
+	HiddenFadeOut   float64 // Hidden: how long the object takes to fade out
+	HiddenInvisible float64 // Hidden: how long before its hit time the object is gone
 }
 
 func GetBeatmapConstants(
@@ -17,6 +24,9 @@ func GetBeatmapConstants(
 	mods Modifiers,
 ) MapConstants {
 	cs := beatmap.Difficulty.CircleSize
+	if mods.DifficultyAdjust.CircleSize != nil {
+		cs = *mods.DifficultyAdjust.CircleSize
+	}
 	if mods.Hardrock {
 		cs = min(cs*1.3, 10)
 	}
@@ -27,8 +37,14 @@ func GetBeatmapConstants(
 	circleRadius := 54.4 - 4.48*cs
 
 	ar := beatmap.Difficulty.ApproachRate
+	if mods.DifficultyAdjust.ApproachRate != nil {
+		ar = *mods.DifficultyAdjust.ApproachRate
+	}
 
 	od := beatmap.Difficulty.OverallDifficulty
+	if mods.DifficultyAdjust.OverallDifficulty != nil {
+		od = *mods.DifficultyAdjust.OverallDifficulty
+	}
 	if mods.Hardrock {
 		od = min(10, od*1.4)
 	}
@@ -43,12 +59,21 @@ func GetBeatmapConstants(
 		ar = ar / 2
 	}
 
-	preempt := ApproachRateToPreempt(ar) / mods.Rate
-	ar = PreemptToAR(preempt)
+	preempt := ApproachRateToPreempt(ar)
+	ar = PreemptToAR(preempt / mods.Rate)
 
-	window300 := (80 - 6*od) / mods.Rate //+- this
-	window100 := (140 - 8*od) / mods.Rate
-	window50 := (200 - 10*od) / mods.Rate
+	fadeIn := 400 * min(1, preempt/450)
+	var hiddenFadeOut, hiddenInvisible float64
+	if mods.Hidden {
+		// fades in over 40% of the preempt, then straight out over the next 30%
+		fadeIn = preempt * 0.4
+		hiddenFadeOut = preempt * 0.3
+		hiddenInvisible = preempt - fadeIn - hiddenFadeOut
+	}
+
+	window300 := 80 - 6*od //+- this
+	window100 := 140 - 8*od
+	window50 := 200 - 10*od
 
 	return MapConstants{
 		Mods:         mods,
@@ -58,5 +83,9 @@ func GetBeatmapConstants(
 		Window300:    window300,
 		Window100:    window100,
 		Window50:     window50,
+
+		FadeIn:          fadeIn,
+		HiddenFadeOut:   hiddenFadeOut,
+		HiddenInvisible: hiddenInvisible,
 	}
 }
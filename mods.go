@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// ScoreMod is one mod of a score. Legacy scores list acronyms, lazer scores list objects with
+// the acronym and the settings that differ from the defaults.
+type ScoreMod struct {
+	Acronym  string          `json:"acronym"`
+	Settings json.RawMessage `json:"settings,omitempty"`
+}
+
+func (m *ScoreMod) UnmarshalJSON(data []byte) error {
+	var acronym string
+	if err := json.Unmarshal(data, &acronym); err == nil {
+		*m = ScoreMod{Acronym: acronym}
+		return nil
+	}
+	type scoreMod ScoreMod
+	return json.Unmarshal(data, (*scoreMod)(m))
+}
+
+// lazer mod settings, for the mods whose settings change the calculation
+type modSettings struct {
+	SpeedChange *float64 `json:"speed_change"`
+	InitialRate *float64 `json:"initial_rate"`
+	FinalRate   *float64 `json:"final_rate"`
+
+	// Difficulty Adjust
+	CircleSize        *float64 `json:"circle_size"`
+	ApproachRate      *float64 `json:"approach_rate"`
+	OverallDifficulty *float64 `json:"overall_difficulty"`
+	DrainRate         *float64 `json:"drain_rate"`
+	ExtendedLimits    bool     `json:"extended_limits"`
+
+	// Classic
+	NoSliderHeadAccuracy *bool `json:"no_slider_head_accuracy"`
+
+	// Mirror: 0 horizontal, 1 vertical, 2 both
+	Reflection *int `json:"reflection"`
+}
+
+func (m ScoreMod) settings() (modSettings, error) {
+	var s modSettings
+	if len(m.Settings) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(m.Settings, &s); err != nil {
+		return s, fmt.Errorf("%s settings: %w", m.Acronym, err)
+	}
+	return s, nil
+}
+
+func settingOr(value *float64, def float64) float64 {
+	if value == nil {
+		return def
+	}
+	return *value
+}
+
+// DifficultyAdjust overrides beatmap difficulty settings; nil keeps the beatmap value.
+type DifficultyAdjust struct {
+	CircleSize        *float64
+	ApproachRate      *float64
+	OverallDifficulty *float64
+	DrainRate         *float64
+}
+
+func (da DifficultyAdjust) IsSet() bool {
+	return da.CircleSize != nil || da.ApproachRate != nil || da.OverallDifficulty != nil || da.DrainRate != nil
+}
+
+// clampSetting keeps a Difficulty Adjust value within the slider range lazer allows.
+func clampSetting(value *float64, lo, hi float64) *float64 {
+	if value == nil {
+		return nil
+	}
+	clamped := min(hi, max(lo, *value))
+	return &clamped
+}
+
+func newDifficultyAdjust(s modSettings) DifficultyAdjust {
+	maxValue := 10.0
+	minAR := 0.0
+	if s.ExtendedLimits {
+		maxValue = 11
+		minAR = -10
+	}
+	return DifficultyAdjust{
+		CircleSize:        clampSetting(s.CircleSize, 0, maxValue),
+		ApproachRate:      clampSetting(s.ApproachRate, minAR, maxValue),
+		OverallDifficulty: clampSetting(s.OverallDifficulty, 0, maxValue),
+		DrainRate:         clampSetting(s.DrainRate, 0, maxValue),
+	}
+}
+
+func HasMod(mods []ScoreMod, acronym string) bool {
+	return slices.ContainsFunc(mods, func(m ScoreMod) bool { return m.Acronym == acronym })
+}
+
+func ParseModifiers(lazer bool, mods []ScoreMod) (Modifiers, error) {
+	modifiers := Modifiers{
+		Lazer: lazer,
+
+		Rate:       1.0,
+		Hardrock:   HasMod(mods, "HR"),
+		Easy:       HasMod(mods, "EZ"),
+		Hidden:     HasMod(mods, "HD"),
+		Flashlight: HasMod(mods, "FL"),
+		NoFail:     HasMod(mods, "NF"),
+		SpunOut:    HasMod(mods, "SO"),
+	}
+	for _, mod := range mods {
+		settings, err := mod.settings()
+		if err != nil {
+			return modifiers, err
+		}
+		switch mod.Acronym {
+		case "DT", "NC":
+			modifiers.Rate = settingOr(settings.SpeedChange, 1.5)
+		case "HT", "DC":
+			modifiers.Rate = settingOr(settings.SpeedChange, 0.75)
+		case "WU":
+			modifiers.Rate = settingOr(settings.InitialRate, 1)
+			modifiers.FinalRate = settingOr(settings.FinalRate, 1.5)
+		case "WD":
+			modifiers.Rate = settingOr(settings.InitialRate, 1)
+			modifiers.FinalRate = settingOr(settings.FinalRate, 0.75)
+		case "AS":
+			// the rate follows the player's hits, see AdaptToMisses
+			modifiers.Rate = settingOr(settings.InitialRate, 1)
+			modifiers.AdaptiveSpeed = true
+		case "MR":
+			reflection := 0
+			if settings.Reflection != nil {
+				reflection = *settings.Reflection
+			}
+			if reflection < 0 || reflection > 2 {
+				return modifiers, fmt.Errorf("MR settings: unknown reflection %d", reflection)
+			}
+			modifiers.Mirror = MirrorHorizontal + MirrorAxes(reflection)
+		case "DA":
+			modifiers.DifficultyAdjust = newDifficultyAdjust(settings)
+		case "CL":
+			modifiers.ClassicSliderHeads = settings.NoSliderHeadAccuracy == nil || *settings.NoSliderHeadAccuracy
+		case "ST":
+			modifiers.StrictTracking = true
+		case "TC":
+			modifiers.Traceable = true
+		case "BL":
+			modifiers.Blinds = true
+		}
+	}
+	return modifiers, nil
+}
+
+// ---------- rate ----------
+
+// lazer ModTimeRamp reaches the final rate 75% into the map
+const rateRampProgress = 0.75
+
+// lazer ModAdaptiveSpeed: the rate drops by this factor on every miss, down to the minimum
+const (
+	adaptiveSpeedMissFactor = 0.95
+	adaptiveSpeedMinRate    = 0.5
+)
+
+// AdaptToMisses gives Adaptive Speed a rate that changes over the map. The score doesn't record
+// how the rate followed the player's hits, so it is taken to drop by adaptiveSpeedMissFactor
+// per miss with the misses spread evenly over the map; speeding up on well timed hits is not
+// modelled.
+func (mods Modifiers) AdaptToMisses(misses int) Modifiers {
+	if mods.AdaptiveSpeed && misses > 0 {
+		mods.FinalRate = max(adaptiveSpeedMinRate, mods.Rate*math.Pow(adaptiveSpeedMissFactor, float64(misses)))
+	}
+	return mods
+}
+
+// RateCurve is the clock rate over map time. It is constant unless Wind Up/Down or Adaptive
+// Speed ramp it linearly from Initial at Start to Final at End.
+type RateCurve struct {
+	Initial float64
+	Final   float64
+	Start   float64
+	End     float64
+}
+
+// RateCurve spans the ramp over the map the way lazer does, from the first object's start to
+// rateRampProgress of the way to the last object's end. Adaptive Speed ramps to the end.
+func (mods Modifiers) RateCurve(firstObjectStart, lastObjectEnd float64) RateCurve {
+	curve := RateCurve{
+		Initial: mods.Rate,
+		Final:   mods.Rate,
+	}
+	if mods.FinalRate > 0 {
+		curve.Final = mods.FinalRate
+		curve.Start = firstObjectStart
+		curve.End = firstObjectStart + rateRampProgress*(lastObjectEnd-firstObjectStart)
+		if mods.AdaptiveSpeed {
+			curve.End = lastObjectEnd
+		}
+	}
+	return curve
+}
+
+func (c RateCurve) RateAt(time float64) float64 {
+	if c.Initial == c.Final {
+		return c.Initial
+	}
+	amount := (time - c.Start) / max(1, c.End-c.Start)
+	return c.Initial + (c.Final-c.Initial)*min(1, max(0, amount))
+}
+
+// RealTime converts map time to the time the player experiences, the integral of 1/rate.
+func (c RateCurve) RealTime(time float64) float64 {
+	if c.Initial == c.Final {
+		return time / c.Initial
+	}
+	if time <= c.Start {
+		return time / c.Initial
+	}
+	duration := max(1, c.End-c.Start)
+	slope := (c.Final - c.Initial) / duration
+	ramped := min(time, c.Start+duration)
+	realTime := c.Start/c.Initial + math.Log(c.RateAt(ramped)/c.Initial)/slope
+	if time > ramped {
+		realTime += (time - ramped) / c.Final
+	}
+	return realTime
+}
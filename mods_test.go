@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestParseModifiers(t *testing.T) {
+	tests := []struct {
+		mods      string
+		rate      float64
+		finalRate float64
+	}{
+		{`["HD","DT"]`, 1.5, 0},
+		{`["NC"]`, 1.5, 0},
+		{`["DC"]`, 0.75, 0},
+		{`[{"acronym":"DT","settings":{"speed_change":1.3}}]`, 1.3, 0},
+		{`[{"acronym":"HT","settings":{"speed_change":0.6}}]`, 0.6, 0},
+		{`[{"acronym":"WU"}]`, 1, 1.5},
+		{`[{"acronym":"WD","settings":{"initial_rate":1.2,"final_rate":0.8}}]`, 1.2, 0.8},
+		{`[{"acronym":"AS","settings":{"initial_rate":1.1}}]`, 1.1, 0},
+	}
+	for _, test := range tests {
+		var mods []ScoreMod
+		if err := json.Unmarshal([]byte(test.mods), &mods); err != nil {
+			t.Fatal(err)
+		}
+		modifiers, err := ParseModifiers(true, mods)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if modifiers.Rate != test.rate || modifiers.FinalRate != test.finalRate {
+			t.Errorf("%s: got rate %v, final rate %v", test.mods, modifiers.Rate, modifiers.FinalRate)
+		}
+	}
+}
+
+func TestParseLazerModSettings(t *testing.T) {
+	var mods []ScoreMod
+	data := `[
+		{"acronym":"DA","settings":{"approach_rate":11,"circle_size":12,"extended_limits":true}},
+		{"acronym":"CL","settings":{"no_slider_head_accuracy":false}},
+		{"acronym":"ST"},
+		"TC"
+	]`
+	if err := json.Unmarshal([]byte(data), &mods); err != nil {
+		t.Fatal(err)
+	}
+	modifiers, err := ParseModifiers(true, mods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	da := modifiers.DifficultyAdjust
+	if da.ApproachRate == nil || *da.ApproachRate != 11 || da.CircleSize == nil || *da.CircleSize != 11 || da.OverallDifficulty != nil {
+		t.Errorf("difficulty adjust: %+v", da)
+	}
+	if modifiers.ClassicSliderHeads || !modifiers.StrictTracking || !modifiers.Traceable || modifiers.Blinds {
+		t.Errorf("got %+v", modifiers)
+	}
+
+	modifiers, err = ParseModifiers(true, []ScoreMod{{Acronym: "CL"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modifiers.ClassicSliderHeads {
+		t.Error("Classic without settings keeps the slider head leniency")
+	}
+}
+
+func TestRateCurveRealTime(t *testing.T) {
+	curve := Modifiers{Rate: 1, FinalRate: 1.5}.RateCurve(1000, 5000)
+	if curve.End != 4000 {
+		t.Fatalf("ramp ends at %v", curve.End)
+	}
+	// integrate 1/rate in small steps
+	realTime := 0.0
+	const step = 0.01
+	for time := 0.0; time < 6000; time += step {
+		realTime += step / curve.RateAt(time+step/2)
+		if math.Abs(curve.RealTime(time+step)-realTime) > 1e-3 {
+			t.Fatalf("at %v: got %v, want %v", time+step, curve.RealTime(time+step), realTime)
+		}
+	}
+
+	constant := Modifiers{Rate: 1.5}.RateCurve(1000, 5000)
+	if got := constant.RealTime(3000); got != 2000 {
+		t.Errorf("constant rate: got %v", got)
+	}
+}
+
+func TestAdaptiveSpeedRateCurve(t *testing.T) {
+	mods := Modifiers{Rate: 1.2, AdaptiveSpeed: true}
+	if got := mods.AdaptToMisses(0).RateCurve(1000, 5000); got.Initial != 1.2 || got.Final != 1.2 {
+		t.Errorf("full combo: %+v", got)
+	}
+	curve := mods.AdaptToMisses(2).RateCurve(1000, 5000)
+	if !closeTo(curve.Final, 1.2*0.95*0.95) || curve.End != 5000 {
+		t.Errorf("two misses: %+v", curve)
+	}
+	if got := mods.AdaptToMisses(100).FinalRate; got != 0.5 {
+		t.Errorf("final rate %v, want the minimum", got)
+	}
+}
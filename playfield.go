@@ -0,0 +1,78 @@
+package main
+
+import "ppv3/dotosu"
+
+const (
+	PlayfieldWidth  = 512
+	PlayfieldHeight = 384
+)
+
+// lazer Mirror mod "Reflection" setting
+type MirrorAxes uint8
+
+const (
+	MirrorNone MirrorAxes = iota
+	MirrorHorizontal
+	MirrorVertical
+	MirrorBoth
+)
+
+type PlayfieldTransform struct {
+	FlipX bool
+	FlipY bool
+}
+
+func (mods Modifiers) PlayfieldTransform() PlayfieldTransform {
+	t := PlayfieldTransform{
+		FlipX: mods.Mirror == MirrorHorizontal || mods.Mirror == MirrorBoth,
+		FlipY: mods.Mirror == MirrorVertical || mods.Mirror == MirrorBoth,
+	}
+	if mods.Hardrock {
+		t.FlipY = !t.FlipY
+	}
+	return t
+}
+
+func (t PlayfieldTransform) IsIdentity() bool {
+	return !t.FlipX && !t.FlipY
+}
+
+func (t PlayfieldTransform) ApplyToPos(p dotosu.Vec2) dotosu.Vec2 {
+	if t.FlipX {
+		p.X = PlayfieldWidth - p.X
+	}
+	if t.FlipY {
+		p.Y = PlayfieldHeight - p.Y
+	}
+	return p
+}
+
+// ApplyToObject returns a mirrored copy of the object, the beatmap itself is left untouched.
+// Slider control points are mirrored too, so the approximated path, ticks and ends follow.
+func (t PlayfieldTransform) ApplyToObject(object dotosu.HitObject) dotosu.HitObject {
+	if t.IsIdentity() {
+		return object
+	}
+	switch object := object.(type) {
+	case dotosu.Circle:
+		object.PosXY = t.ApplyToPos(object.PosXY)
+		return object
+	case dotosu.Slider:
+		object.PosXY = t.ApplyToPos(object.PosXY)
+		segments := make([]dotosu.SliderSegment, len(object.Path.Segments))
+		for i, segment := range object.Path.Segments {
+			points := make([]dotosu.Vec2, len(segment.Points))
+			for j, p := range segment.Points {
+				points[j] = t.ApplyToPos(p)
+			}
+			segments[i] = dotosu.SliderSegment{Points: points}
+		}
+		object.Path.Segments = segments
+		return object
+	case dotosu.Spinner:
+		object.PosXY = t.ApplyToPos(object.PosXY)
+		return object
+	default:
+		return object
+	}
+}
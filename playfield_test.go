@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"ppv3/dotosu"
+	"reflect"
+	"testing"
+)
+
+func TestPlayfieldTransform(t *testing.T) {
+	slider := dotosu.Slider{
+		BaseHO: dotosu.BaseHO{PosXY: dotosu.Vec2{X: 100, Y: 50}},
+		Path: dotosu.SliderPath{
+			Type: dotosu.PathBezier,
+			Segments: []dotosu.SliderSegment{
+				{Points: []dotosu.Vec2{{X: 100, Y: 50}, {X: 200, Y: 80}}},
+				{Points: []dotosu.Vec2{{X: 200, Y: 80}, {X: 300, Y: 0}}},
+			},
+		},
+	}
+	tests := []struct {
+		mods   string
+		points []dotosu.Vec2 // the head, then the control points of both segments
+	}{
+		{`["HR"]`, []dotosu.Vec2{{X: 100, Y: 334}, {X: 100, Y: 334}, {X: 200, Y: 304}, {X: 200, Y: 304}, {X: 300, Y: 384}}},
+		{`["HR","MR"]`, []dotosu.Vec2{{X: 412, Y: 334}, {X: 412, Y: 334}, {X: 312, Y: 304}, {X: 312, Y: 304}, {X: 212, Y: 384}}},
+		{`["HR",{"acronym":"MR","settings":{"reflection":0}}]`, []dotosu.Vec2{{X: 412, Y: 334}, {X: 412, Y: 334}, {X: 312, Y: 304}, {X: 312, Y: 304}, {X: 212, Y: 384}}},
+		// Hard Rock flips it back
+		{`["HR",{"acronym":"MR","settings":{"reflection":1}}]`, []dotosu.Vec2{{X: 100, Y: 50}, {X: 100, Y: 50}, {X: 200, Y: 80}, {X: 200, Y: 80}, {X: 300, Y: 0}}},
+		{`["HR",{"acronym":"MR","settings":{"reflection":2}}]`, []dotosu.Vec2{{X: 412, Y: 50}, {X: 412, Y: 50}, {X: 312, Y: 80}, {X: 312, Y: 80}, {X: 212, Y: 0}}},
+	}
+	for _, test := range tests {
+		var mods []ScoreMod
+		if err := json.Unmarshal([]byte(test.mods), &mods); err != nil {
+			t.Fatal(err)
+		}
+		modifiers, err := ParseModifiers(true, mods)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mirrored := modifiers.PlayfieldTransform().ApplyToObject(slider).(dotosu.Slider)
+		points := []dotosu.Vec2{mirrored.PosXY}
+		for _, segment := range mirrored.Path.Segments {
+			points = append(points, segment.Points...)
+		}
+		if !reflect.DeepEqual(points, test.points) {
+			t.Errorf("%s: got %v, want %v", test.mods, points, test.points)
+		}
+	}
+	if slider.Path.Segments[0].Points[0] != (dotosu.Vec2{X: 100, Y: 50}) {
+		t.Error("the beatmap's slider was changed")
+	}
+
+	if _, err := ParseModifiers(true, []ScoreMod{{Acronym: "MR", Settings: json.RawMessage(`{"reflection":3}`)}}); err == nil {
+		t.Error("unknown reflection parsed")
+	}
+}
@@ -27,7 +27,9 @@ func CalculateBeatmapPPInfo(
 	countSpinnerMisses int,
 ) (*BeatmapPPInfo, error) {
 	fmt.Println(beatmap.Metadata.Title)
+	active := ActiveSkills()
 	ppIter := GradientDescent(
+		active,
 		func(skills Skills) PPIter {
 			iter := NewPPIter(
 				mapConstants,
@@ -44,7 +46,7 @@ func CalculateBeatmapPPInfo(
 				countSliderTickMisses,
 				countSpinnerMisses,
 			)
-			iter.PP = skills.PP()
+			iter.PP = skills.PP(active)
 
 			return iter
 		},
@@ -53,10 +55,18 @@ func CalculateBeatmapPPInfo(
 	mods := mapConstants.Mods
 
 	modsStr := ""
-	if mods.Rate > 1 {
+	switch {
+	case mods.AdaptiveSpeed && mods.FinalRate > 0:
+		modsStr += fmt.Sprintf("AS(%.2f-%.2f)", mods.Rate, mods.FinalRate)
+	case mods.FinalRate > mods.Rate:
+		modsStr += fmt.Sprintf("WU(%.2f-%.2f)", mods.Rate, mods.FinalRate)
+	case mods.FinalRate > 0 && mods.FinalRate < mods.Rate:
+		modsStr += fmt.Sprintf("WD(%.2f-%.2f)", mods.Rate, mods.FinalRate)
+	case mods.AdaptiveSpeed:
+		modsStr += fmt.Sprintf("AS(%.2f)", mods.Rate)
+	case mods.Rate > 1:
 		modsStr += fmt.Sprintf("DT(%.2f)", mods.Rate)
-	}
-	if mods.Rate < 1 {
+	case mods.Rate < 1:
 		modsStr += fmt.Sprintf("HT(%.2f)", mods.Rate)
 	}
 	if mods.Easy {
@@ -65,9 +75,15 @@ func CalculateBeatmapPPInfo(
 	if mods.Hardrock {
 		modsStr += "HR"
 	}
+	if mods.Mirror != MirrorNone {
+		modsStr += "MR"
+	}
 	if mods.Hidden {
 		modsStr += "HD"
 	}
+	if mods.Traceable {
+		modsStr += "TC"
+	}
 	if mods.Flashlight {
 		modsStr += "FL"
 	}
@@ -77,6 +93,18 @@ func CalculateBeatmapPPInfo(
 	if mods.SpunOut {
 		modsStr += "SO"
 	}
+	if mods.DifficultyAdjust.IsSet() {
+		modsStr += "DA"
+	}
+	if mods.ClassicSliderHeads {
+		modsStr += "CL"
+	}
+	if mods.StrictTracking {
+		modsStr += "ST"
+	}
+	if mods.Blinds {
+		modsStr += "BL"
+	}
 
 	if modsStr == "" {
 		modsStr = "NM"
@@ -17,9 +17,14 @@ func ProbabilityToAim(
 	radius := action.Radius
 
 	expectedDistanceError := 0.001 * distance * jumpBpm / math.Pow(it.Skills.Aim.DistancePrecision, 0.5)
+	expectedDistanceError *= StaminaAimError(it, action)
 
 	expectedAngleError := 30 / (1 + it.Skills.Aim.AnglePrecision)
 
+	readingError := ReadingError(it, action)
+	expectedDistanceError *= readingError
+	expectedAngleError *= readingError
+
 	if action.Clickable {
 		timeOverObject := deltaTime * radius / distance // time over object assuming constant cursor speed
 		expectedDistanceError *= 1 + 0.1*unstableRate/timeOverObject
@@ -43,8 +48,30 @@ func ProbabilitiesToAimAndTap(
 		action,
 		unstableRate,
 	)
-	atLeast300 = pAim * ProbErrLessThanX(unstableRate, it.MapConstants.Window300)
-	atLeast100 = pAim * ProbErrLessThanX(unstableRate, it.MapConstants.Window100)
-	atLeast50 = pAim * ProbErrLessThanX(unstableRate, it.MapConstants.Window50)
+	atLeast300 = pAim * ProbErrLessThanX(unstableRate, it.MapConstants.Window300/action.Rate)
+	atLeast100 = pAim * ProbErrLessThanX(unstableRate, it.MapConstants.Window100/action.Rate)
+	atLeast50 = pAim * ProbErrLessThanX(unstableRate, it.MapConstants.Window50/action.Rate)
 	return
 }
+
+// followLag is how far behind the ball a player of SliderTracking 1 follows it, in ms. The cursor
+// trails by the ball's movement over that time, and more when the path turns within it.
+const followLag = 100
+
+// ProbabilityToFollow is the chance to keep the cursor inside the follow circle while the
+// ball moves from the previous slider action to this one.
+func ProbabilityToFollow(
+	it *PPIter,
+	action *Action,
+) float64 {
+	lastAim := action.LastAims[len(action.LastAims)-1]
+	deltaTime := max(1, action.Time-lastAim.Time)
+
+	speed := action.FollowDistance / deltaTime   // 1 = 1000 osu!pixels per second
+	turnRate := action.FollowTurning / deltaTime // radians per ms
+
+	// distance the ball covers during the lag, times one plus the radians it turns meanwhile
+	expectedTrackingError := followLag * speed * (1 + followLag*turnRate) / math.Pow(it.Skills.Aim.SliderTracking, 0.5)
+
+	return ProbErrLessThanX(expectedTrackingError, action.Radius)
+}
@@ -18,6 +18,13 @@ type PPIter struct {
 	ProbNSpinnerMisses    KMisses
 
 	SliderProbs StableSliderProbs
+
+	// state of the play so far, assuming a full combo
+	Combo   int
+	Sliding bool
+
+	// Strict Tracking: chance the current slider hasn't been broken yet
+	TrackingProb float64
 }
 
 type StableSliderProbs struct {
@@ -78,6 +85,12 @@ type TimePos struct {
 	Time   float64
 }
 
+// stableSliders: stable, and lazer with Classic's slider head leniency, judge a slider as a
+// whole from its head and nested objects instead of judging them one by one.
+func (it *PPIter) stableSliders() bool {
+	return !it.MapConstants.Mods.Lazer || it.MapConstants.Mods.ClassicSliderHeads
+}
+
 func IterateAction(
 	it *PPIter,
 	action *Action,
@@ -88,11 +101,16 @@ func IterateAction(
 			action,
 		)
 
-		if it.MapConstants.Mods.Lazer || action.Circle {
+		if !action.Circle {
+			it.TrackingProb = 1
+		}
+
+		if action.Circle || !it.stableSliders() {
 			it.ProbN100sOr50sOrMisses.Add(atLeast300)
 			it.ProbN50sOrMisses.Add(atLeast100)
 			it.ProbNMisses.Add(atLeast50)
 		} else {
+			// any hit within the 50 window is a full hit for the head
 			it.SliderProbs = StableSliderProbs{
 				P300: atLeast50,
 				P100: 0,
@@ -106,26 +124,35 @@ func IterateAction(
 		)
 		actionProb := pAim
 		if action.Spinner {
-			actionProb /= (1 + it.MapConstants.Window50/it.Skills.Aim.Spin)
+			actionProb /= (1 + it.MapConstants.Window50/action.Rate/it.Skills.Aim.Spin)
+		} else if it.MapConstants.Mods.StrictTracking {
+			// leaving the follow circle once misses everything left of the slider
+			it.TrackingProb *= ProbabilityToFollow(it, action)
+			actionProb *= it.TrackingProb
 		} else {
-			actionProb /= (1 + 0.1/it.Skills.Tapping.HoldSliders)
+			actionProb *= ProbabilityToFollow(it, action)
 		}
-		if it.MapConstants.Mods.Lazer {
-			if action.SliderTick {
-				it.ProbNSliderTickMisses.Add(actionProb)
-			} else if action.SliderEnd {
-				it.ProbNSliderEndMisses.Add(actionProb)
-			} else if action.Spinner {
+		if action.Spinner {
+			if it.MapConstants.Mods.Lazer {
 				it.ProbNSpinnerMisses.Add(actionProb)
-			} else {
-				panic("unexpected case")
-			}
-		} else {
-			if action.Spinner { // either 300 or miss for now
+			} else { // either 300 or miss for now
 				it.ProbN100sOr50sOrMisses.Add(actionProb)
 				it.ProbN50sOrMisses.Add(actionProb)
 				it.ProbNMisses.Add(actionProb)
-			} else { //part of slider
+			}
+		} else {
+			// lazer counts tick and end misses even when Classic judges the slider as a whole
+			if it.MapConstants.Mods.Lazer {
+				if action.SliderTick {
+					it.ProbNSliderTickMisses.Add(actionProb)
+				} else if action.SliderEnd {
+					it.ProbNSliderEndMisses.Add(actionProb)
+				} else {
+					panic("unexpected case")
+				}
+			}
+			if it.stableSliders() {
+				// part of a slider judged as a whole
 				prob := it.SliderProbs
 				it.SliderProbs = StableSliderProbs{
 					P300: prob.P300 * actionProb,
@@ -134,7 +161,7 @@ func IterateAction(
 						(1-prob.P300-prob.P100)*(actionProb),
 				}
 
-				if action.SliderEnd {
+				if action.SliderLast {
 					prob := it.SliderProbs
 					it.ProbN100sOr50sOrMisses.Add(prob.P300)
 					it.ProbN50sOrMisses.Add(prob.P300 + prob.P100)
@@ -143,4 +170,11 @@ func IterateAction(
 			}
 		}
 	}
+
+	it.Combo++
+	if action.Clickable {
+		it.Sliding = !action.Circle
+	} else if action.SliderLast {
+		it.Sliding = false
+	}
 }
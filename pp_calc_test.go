@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"ppv3/dotosu"
+	"strings"
+	"testing"
+)
+
+// tickSliders is a map of sliders with two ticks each, far enough apart to be read one by one.
+func tickSliders(t *testing.T) *dotosu.Beatmap {
+	t.Helper()
+	var sb strings.Builder
+	sb.WriteString("osu file format v14\n\n[Difficulty]\nCircleSize:4\nOverallDifficulty:8\nApproachRate:9\nSliderMultiplier:1\nSliderTickRate:2\n\n")
+	sb.WriteString("[TimingPoints]\n0,500,4,2,0,100,1,0\n\n[HitObjects]\n")
+	for i := range 8 {
+		fmt.Fprintf(&sb, "%d,192,%d,2,0,L|%d:192,1,150\n", 100+i%2*150, 1000+i*1000, 250-i%2*150)
+	}
+	beatmap, err := dotosu.Decode(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return beatmap
+}
+
+func TestClassicCountsTickMisses(t *testing.T) {
+	beatmap := tickSliders(t)
+	mods := Modifiers{Rate: 1, Lazer: true, ClassicSliderHeads: true}
+	mapConstants := GetBeatmapConstants(beatmap, mods)
+	actions, err := ConvertBeatmapToActions(mapConstants, beatmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp := func(tickMisses int) float64 {
+		info, err := CalculateBeatmapPPInfo(beatmap, mapConstants, actions, 0, 0, 0, 0, tickMisses, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Iter.PP
+	}
+	if clean, missed := pp(0), pp(3); missed >= clean {
+		t.Errorf("3 tick misses: %v pp, clean: %v pp", missed, clean)
+	}
+}
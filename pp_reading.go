@@ -0,0 +1,104 @@
+package main
+
+import "math"
+
+const (
+	// lazer OsuModFlashlight.DefaultFlashlightSize: the radius in osu!pixels at size multiplier 1,
+	// before the combo scale of ModFlashlight.Flashlight.GetComboScaleFor
+	flashlightRadius = 200
+	// lazer OsuFlashlight.OnSliderTrackingChange: FlashlightDim, the alpha of the dark layer put
+	// over the whole playfield, the lit circle included, while a slider is tracked
+	flashlightSliderDim = 0.8
+)
+
+// ReadingError combines the errors of every mod that hides part of the map.
+func ReadingError(
+	it *PPIter,
+	action *Action,
+) float64 {
+	return HiddenReadingError(it, action) *
+		TraceableReadingError(it, action) *
+		FlashlightReadingError(it, action) *
+		BlindsReadingError(it, action)
+}
+
+// HiddenReadingError scales the aim and tap errors of an action played with Hidden.
+// The object fades out before it has to be hit, so the player plays it from memory; that is
+// harder the longer it has been gone and the more objects were on screen with it. An object
+// counts as seen once half faded in and as gone once half faded out.
+func HiddenReadingError(
+	it *PPIter,
+	action *Action,
+) float64 {
+	if !it.MapConstants.Mods.Hidden {
+		return 1
+	}
+	constants := it.MapConstants
+	memoryTime := (constants.HiddenInvisible + constants.HiddenFadeOut/2) / action.Rate
+	appearTime := action.Time - (constants.Preempt-constants.FadeIn/2)/action.Rate
+	// objects that were still visible when this one appeared
+	visibleObjects := 0
+	for i := len(action.LastAims) - 1; i >= 0; i-- {
+		if action.LastAims[i].Time-memoryTime < appearTime {
+			break
+		}
+		visibleObjects++
+	}
+	return 1 + 0.001*memoryTime*float64(1+visibleObjects)/it.Skills.Reading.HiddenReading
+}
+
+// FlashlightRadius shrinks at 100 and 200 combo, like GetComboScaleFor.
+func FlashlightRadius(combo int) float64 {
+	switch {
+	case combo >= 200:
+		return flashlightRadius * 0.625
+	case combo >= 100:
+		return flashlightRadius * 0.8125
+	}
+	return flashlightRadius
+}
+
+// FlashlightReadingError scales the aim and tap errors of an action played with Flashlight.
+// Objects further from the cursor than the flashlight reaches have to be played from memory,
+// and while a slider is held even the lit ones only show at 1-flashlightSliderDim brightness.
+func FlashlightReadingError(
+	it *PPIter,
+	action *Action,
+) float64 {
+	if !it.MapConstants.Mods.Flashlight {
+		return 1
+	}
+	radius := FlashlightRadius(it.Combo)
+	lastAim := action.LastAims[len(action.LastAims)-1]
+	outside := max(0, Distance(lastAim.Pos, action.Pos)+action.Radius-radius) / radius
+	dim := 0.0
+	if it.Sliding {
+		dim = flashlightSliderDim
+	}
+	// a fully dark object costs about as much as one half a radius outside the light
+	return 1 + (outside+0.5*dim)/it.Skills.Reading.Flashlight
+}
+
+// TraceableReadingError: with Traceable only the approach circle shows where to click.
+func TraceableReadingError(
+	it *PPIter,
+	action *Action,
+) float64 {
+	if !it.MapConstants.Mods.Traceable {
+		return 1
+	}
+	return 1 + 0.0005*it.MapConstants.Preempt/action.Rate/it.Skills.Reading.Traceable
+}
+
+// BlindsReadingError: blinds close in from the top and the bottom of the playfield. They stay
+// mostly open at the high health of a full combo, so only objects near the edges suffer.
+func BlindsReadingError(
+	it *PPIter,
+	action *Action,
+) float64 {
+	if !it.MapConstants.Mods.Blinds {
+		return 1
+	}
+	edge := math.Abs(action.Pos.Y-PlayfieldHeight/2) / (PlayfieldHeight / 2)
+	return 1 + 0.1*edge*edge/it.Skills.Reading.Blinds
+}
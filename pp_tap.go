@@ -15,7 +15,7 @@ func GetUnstableRate(
 	avgBpmTo300 := 0.0
 	for i := 1; i <= fakeObjects; i++ {
 		deltaTime := (action.Time - action.LastClicks[len(action.LastClicks)-i].Time)
-		avgBpmTo300 = max(avgBpmTo300, float64(i)*15000/(deltaTime+it.MapConstants.Window300*2))
+		avgBpmTo300 = max(avgBpmTo300, float64(i)*15000/(deltaTime+it.MapConstants.Window300/action.Rate*2))
 	}
 
 	skillBurstBPM := math.Sqrt(it.Skills.Tapping.BurstSpeed) * 10   // 900 skill in speed = 300 bpm
@@ -24,9 +24,14 @@ func GetUnstableRate(
 		0.1*math.Pow(lastClickBPM/skillBurstBPM, 2) +
 		math.Pow(avgBpmTo300/skillStreamBPM, 3)
 
-	lowArClickError := (1 + 0.001*it.MapConstants.Preempt/it.Skills.Reading.LowAr)
+	lowArClickError := (1 + 0.001*it.MapConstants.Preempt/action.Rate/it.Skills.Reading.LowAr)
 
-	return speedErrorFactor * lowArClickError * (10000 / (1 + 2*it.Skills.Tapping.Accuracy))
+	return speedErrorFactor * lowArClickError *
+		StaminaTapError(it, action) *
+		PatternTapError(it, action) *
+		RhythmComplexityError(it, action) *
+		ReadingError(it, action) *
+		(10000 / (1 + 2*it.Skills.Tapping.Accuracy))
 }
 
 // unstable rate calcs
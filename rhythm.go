@@ -0,0 +1,188 @@
+package main
+
+// Burst is a run of clicks at about the same spacing, shorter than the spacing around it.
+// Clicks that aren't part of any burst are singles, bursts of length 1.
+type Burst struct {
+	Clicks []*Action
+	Gap    float64 // ms between its clicks, 0 for a single
+}
+
+// SegmentBursts groups the clicks of the map into bursts by the ratio of the time gaps
+// between them. A gap streamBreakRatio longer or shorter than the one before starts a new
+// group; groups whose spacing isn't shorter than the gaps on both sides split into singles.
+func SegmentBursts(
+	actions []*Action,
+) []Burst {
+	var clicks []*Action
+	for _, action := range actions {
+		if action.Clickable {
+			clicks = append(clicks, action)
+		}
+	}
+
+	type run struct {
+		start, end int // clicks[start:end]
+		gap        float64
+	}
+	var runs []run
+	start := 0
+	runGap := 0.0
+	for i := 1; i < len(clicks); i++ {
+		gap := clicks[i].Time - clicks[i-1].Time
+		switch {
+		case i-1 == start:
+			runGap = gap
+		case gap > runGap*streamBreakRatio:
+			runs = append(runs, run{start, i, runGap})
+			start = i
+		case gap*streamBreakRatio < runGap:
+			// the click before belongs to the faster run starting here
+			runs = append(runs, run{start, i - 1, runGap})
+			start = i - 1
+			runGap = gap
+		}
+	}
+	if len(clicks) > 0 {
+		runs = append(runs, run{start, len(clicks), runGap})
+	}
+
+	bursts := make([]Burst, 0, len(runs))
+	for _, r := range runs {
+		isBurst := r.end-r.start > 1 && (r.start > 0 || r.end < len(clicks))
+		if r.start > 0 {
+			isBurst = isBurst && clicks[r.start].Time-clicks[r.start-1].Time > r.gap*streamBreakRatio
+		}
+		if r.end < len(clicks) {
+			isBurst = isBurst && clicks[r.end].Time-clicks[r.end-1].Time > r.gap*streamBreakRatio
+		}
+		if isBurst {
+			bursts = append(bursts, Burst{Clicks: clicks[r.start:r.end], Gap: r.gap})
+			continue
+		}
+		for _, click := range clicks[r.start:r.end] {
+			bursts = append(bursts, Burst{Clicks: []*Action{click}})
+		}
+	}
+	return bursts
+}
+
+// snapGrid is the family of snap divisors a click is on
+type snapGrid uint8
+
+const (
+	gridAny     snapGrid = iota // 1/1 and 1/2 are on both grids
+	gridEven                    // 1/4, 1/8, 1/16
+	gridTriplet                 // 1/3, 1/6, 1/12
+	gridNone                    // unsnapped
+)
+
+func snapGridOf(divisor int) snapGrid {
+	switch divisor {
+	case 1, 2:
+		return gridAny
+	case 4, 8, 16:
+		return gridEven
+	case 3, 6, 12:
+		return gridTriplet
+	}
+	return gridNone
+}
+
+func PrecalculateRhythm(
+	actions []*Action,
+) {
+	lastGrid := gridAny
+	for _, action := range actions {
+		if !action.Clickable {
+			continue
+		}
+		grid := snapGridOf(action.Snap)
+		if grid == gridAny {
+			continue
+		}
+		action.SnapChange = lastGrid != gridAny && grid != lastGrid
+		lastGrid = grid
+	}
+
+	prevLength := 0
+	for _, burst := range SegmentBursts(actions) {
+		for i, click := range burst.Clicks {
+			click.BurstLength = len(burst.Clicks)
+			click.BurstPos = i
+			click.PrevBurstLength = prevLength
+		}
+		prevLength = len(burst.Clicks)
+	}
+}
+
+// longer bursts are streams
+const maxBurstLength = 5
+
+// PatternTapError scales the unstable rate of a click by how hard its pattern is to tap at
+// its speed: singles through quints each need their own skill, longer runs are streams.
+// The first click after a burst or stream needs to keep tapping with the hand its parity left.
+func PatternTapError(
+	it *PPIter,
+	action *Action,
+) float64 {
+	if !action.Clickable {
+		return 1
+	}
+	lastClick := action.LastClicks[len(action.LastClicks)-1]
+	bpm := 15000 / max(1, action.Time-lastClick.Time) // 50ms = 300bpm 1/4
+
+	rhythm := it.Skills.Rhythm
+	prevEven := action.PrevBurstLength%2 == 0
+	even := action.BurstLength%2 == 0
+	var skill float64
+	switch {
+	case action.BurstPos > 0:
+		switch action.BurstLength {
+		case 2:
+			skill = rhythm.Doubles
+		case 3:
+			skill = rhythm.Triples
+		case 4:
+			skill = rhythm.Quads
+		case 5:
+			skill = rhythm.Quints
+		default:
+			return 1
+		}
+	case action.PrevBurstLength > maxBurstLength:
+		skill = pick(prevEven, rhythm.EvenStream, rhythm.OddStream)
+	case action.PrevBurstLength > 1 && action.BurstLength == 1:
+		skill = pick(prevEven, rhythm.EvenBurstToSingle, rhythm.OddBurstToSingle)
+	case action.PrevBurstLength > 1 && prevEven:
+		skill = pick(even, rhythm.EvenBurstToEvenBurst, rhythm.EvenBurstToOddBurst)
+	case action.PrevBurstLength > 1:
+		skill = pick(even, rhythm.OddBurstToEvenBurst, rhythm.OddBurstToOddBurst)
+	case action.BurstLength == 1:
+		skill = rhythm.Singles
+	default:
+		// the first click of a burst after a single
+		return 1
+	}
+	return 1 + 0.001*bpm*bpm/skill
+}
+
+func pick(cond bool, ifTrue, ifFalse float64) float64 {
+	if cond {
+		return ifTrue
+	}
+	return ifFalse
+}
+
+// RhythmComplexityError scales the unstable rate of a click that changes the snap grid, like
+// 1/3 against 1/4 in polyrhythms or an unsnapped click.
+func RhythmComplexityError(
+	it *PPIter,
+	action *Action,
+) float64 {
+	if !action.SnapChange {
+		return 1
+	}
+	lastClick := action.LastClicks[len(action.LastClicks)-1]
+	bpm := 15000 / max(1, action.Time-lastClick.Time)
+	return 1 + 0.001*bpm*bpm/it.Skills.Rhythm.Complexity
+}
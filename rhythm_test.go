@@ -0,0 +1,97 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func clicksAt(times ...float64) []*Action {
+	var actions []*Action
+	for _, time := range times {
+		actions = append(actions, &Action{Pos: CenterPos, Time: time, Clickable: true, Circle: true})
+	}
+	return actions
+}
+
+func burstLengths(actions []*Action) []int {
+	var lengths []int
+	for _, burst := range SegmentBursts(actions) {
+		lengths = append(lengths, len(burst.Clicks))
+	}
+	return lengths
+}
+
+func TestSegmentBursts(t *testing.T) {
+	tests := []struct {
+		name  string
+		times []float64
+		want  []int
+	}{
+		{"jumps into a triple", []float64{0, 300, 600, 900, 1000, 1100, 1400}, []int{1, 1, 1, 3, 1}},
+		{"triple, jumps, quint", []float64{0, 100, 200, 500, 800, 900, 1000, 1100, 1200, 1500}, []int{3, 1, 5, 1}},
+		{"doubles", []float64{0, 75, 400, 475, 800, 875}, []int{2, 2, 2}},
+	}
+	for _, test := range tests {
+		if got := burstLengths(clicksAt(test.times...)); !slices.Equal(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	// only clicks count, a slider tick doesn't split the burst
+	actions := clicksAt(0, 100, 200, 500)
+	actions = slices.Insert(actions, 1, &Action{Time: 50})
+	if got := burstLengths(actions); !slices.Equal(got, []int{3, 1}) {
+		t.Errorf("with a slider tick: got %v", got)
+	}
+}
+
+func TestSnapChange(t *testing.T) {
+	// 1/4, 1/3 and unsnapped clicks, with beats and 1/2 in between that fit either grid
+	actions := clicksAt(0, 1, 2, 3, 4, 5, 6, 7, 8)
+	for i, snap := range []int{1, 4, 2, 3, 6, 0, 8, 16, 12} {
+		actions[i].Snap = snap
+	}
+	PrecalculateRhythm(actions)
+	var got []bool
+	for _, action := range actions {
+		got = append(got, action.SnapChange)
+	}
+	if want := []bool{false, false, false, true, false, true, true, false, true}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// patternSkill names the rhythm skill PatternTapError uses for the action, "" for none.
+func patternSkill(action *Action) string {
+	for _, def := range SkillRegistry {
+		if def.Group != "Rhythm" {
+			continue
+		}
+		var skills Skills
+		for _, other := range SkillRegistry {
+			other.Set(&skills, 1e30)
+		}
+		def.Set(&skills, 1)
+		if PatternTapError(&PPIter{Skills: skills}, action) > 1+1e-9 {
+			return def.Name
+		}
+	}
+	return ""
+}
+
+func TestPatternTapError(t *testing.T) {
+	// singles, a double, a single, a triple, a double, a stream of 6 and a single
+	actions := clicksAt(0, 300, 600, 900, 975, 1300, 1600, 1675, 1750, 2100, 2175,
+		2500, 2575, 2650, 2725, 2800, 2875, 3200)
+	PrecalculateActionStuff(actions)
+	// the first click has no click before it to set a speed
+	want := []string{
+		"", "Singles", "Singles", "", "Doubles", "EvenBurstToSingle", "", "Triples", "Triples",
+		"OddBurstToEvenBurst", "Doubles", "EvenBurstToEvenBurst", "", "", "", "", "", "EvenStream",
+	}
+	for i, action := range actions {
+		if got := patternSkill(action); got != want[i] {
+			t.Errorf("click at %v: got %q, want %q", action.Time, got, want[i])
+		}
+	}
+}
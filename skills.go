@@ -1,74 +1,337 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
+	"encoding/json"
 	"fmt"
 	"math"
-	"unsafe"
+	"os"
+	"slices"
 )
 
-const skillCount = 8
-
 type Skills struct {
 	Aim     AimSkills
 	Tapping TappingSkills
 	Reading ReadingSkills
+	Rhythm  RhythmSkills
+}
+
+// SkillDef describes one skill to the fit, the pp and the output.
+type SkillDef struct {
+	Group   string
+	Name    string
+	Default float64 // where the fit starts
+	Min     float64
+	Max     float64
+	Weight  float64 // weight in Skills.PP
+
+	// a disabled skill is pinned to Max, so it never limits a play, and counts for nothing
+	Disabled bool
+
+	field func(*Skills) *float64
+}
+
+func (def SkillDef) Get(skills Skills) float64 {
+	return *def.field(&skills)
+}
+
+func (def SkillDef) Set(skills *Skills, value float64) {
+	*def.field(skills) = value
+}
+
+func skill(group, name string, field func(*Skills) *float64) SkillDef {
+	return SkillDef{
+		Group:   group,
+		Name:    name,
+		Default: 300,
+		Min:     1,
+		Max:     1e30,
+		Weight:  1,
+		field:   field,
+	}
+}
+
+// weighted sets the weight of a skill in Skills.PP.
+func (def SkillDef) weighted(weight float64) SkillDef {
+	def.Weight = weight
+	return def
+}
+
+// Each family of narrower skills shares the weight of one broad skill, so that adding a family
+// doesn't tilt every play towards it. Complexity only adds to the rhythm skills, at half weight.
+const (
+	staminaWeight    = 1.0 / 3  // StaminaStream, StaminaControl, StaminaSingles
+	burstWeight      = 1.0 / 13 // Singles to Quints, the streams and the burst transitions
+	complexityWeight = 0.5
+)
+
+// SkillRegistry lists every field of Skills, with the skills of a group next to each other.
+// Adding a skill means adding its field and its entry here; skills are turned off or
+// reweighted by editing their entry, or for one run with LoadSkillOverrides.
+var SkillRegistry = []SkillDef{
+	skill("Aim", "DistancePrecision", func(s *Skills) *float64 { return &s.Aim.DistancePrecision }),
+	skill("Aim", "AnglePrecision", func(s *Skills) *float64 { return &s.Aim.AnglePrecision }),
+	skill("Aim", "Spin", func(s *Skills) *float64 { return &s.Aim.Spin }),
+	skill("Aim", "SliderTracking", func(s *Skills) *float64 { return &s.Aim.SliderTracking }),
+
+	skill("Tapping", "Accuracy", func(s *Skills) *float64 { return &s.Tapping.Accuracy }),
+	skill("Tapping", "BurstSpeed", func(s *Skills) *float64 { return &s.Tapping.BurstSpeed }),
+	skill("Tapping", "StreamSpeed", func(s *Skills) *float64 { return &s.Tapping.StreamSpeed }),
+	skill("Tapping", "StaminaStream", func(s *Skills) *float64 { return &s.Tapping.StaminaStream }).weighted(staminaWeight),
+	skill("Tapping", "StaminaControl", func(s *Skills) *float64 { return &s.Tapping.StaminaControl }).weighted(staminaWeight),
+	skill("Tapping", "StaminaSingles", func(s *Skills) *float64 { return &s.Tapping.StaminaSingles }).weighted(staminaWeight),
+
+	skill("Reading", "LowAr", func(s *Skills) *float64 { return &s.Reading.LowAr }),
+	skill("Reading", "HiddenReading", func(s *Skills) *float64 { return &s.Reading.HiddenReading }),
+	skill("Reading", "Flashlight", func(s *Skills) *float64 { return &s.Reading.Flashlight }),
+	skill("Reading", "Traceable", func(s *Skills) *float64 { return &s.Reading.Traceable }),
+	skill("Reading", "Blinds", func(s *Skills) *float64 { return &s.Reading.Blinds }),
+
+	skill("Rhythm", "Singles", func(s *Skills) *float64 { return &s.Rhythm.Singles }).weighted(burstWeight),
+	skill("Rhythm", "Doubles", func(s *Skills) *float64 { return &s.Rhythm.Doubles }).weighted(burstWeight),
+	skill("Rhythm", "Triples", func(s *Skills) *float64 { return &s.Rhythm.Triples }).weighted(burstWeight),
+	skill("Rhythm", "Quads", func(s *Skills) *float64 { return &s.Rhythm.Quads }).weighted(burstWeight),
+	skill("Rhythm", "Quints", func(s *Skills) *float64 { return &s.Rhythm.Quints }).weighted(burstWeight),
+	skill("Rhythm", "EvenStream", func(s *Skills) *float64 { return &s.Rhythm.EvenStream }).weighted(burstWeight),
+	skill("Rhythm", "OddStream", func(s *Skills) *float64 { return &s.Rhythm.OddStream }).weighted(burstWeight),
+	skill("Rhythm", "EvenBurstToSingle", func(s *Skills) *float64 { return &s.Rhythm.EvenBurstToSingle }).weighted(burstWeight),
+	skill("Rhythm", "OddBurstToSingle", func(s *Skills) *float64 { return &s.Rhythm.OddBurstToSingle }).weighted(burstWeight),
+	skill("Rhythm", "EvenBurstToOddBurst", func(s *Skills) *float64 { return &s.Rhythm.EvenBurstToOddBurst }).weighted(burstWeight),
+	skill("Rhythm", "EvenBurstToEvenBurst", func(s *Skills) *float64 { return &s.Rhythm.EvenBurstToEvenBurst }).weighted(burstWeight),
+	skill("Rhythm", "OddBurstToOddBurst", func(s *Skills) *float64 { return &s.Rhythm.OddBurstToOddBurst }).weighted(burstWeight),
+	skill("Rhythm", "OddBurstToEvenBurst", func(s *Skills) *float64 { return &s.Rhythm.OddBurstToEvenBurst }).weighted(burstWeight),
+	skill("Rhythm", "Complexity", func(s *Skills) *float64 { return &s.Rhythm.Complexity }).weighted(complexityWeight),
+}
+
+// SkillOverride changes the entry of a skill in SkillRegistry; nil fields keep it as it is.
+type SkillOverride struct {
+	Disabled *bool
+	Weight   *float64
+	Min      *float64
+	Max      *float64
+}
+
+// LoadSkillOverrides returns a copy of SkillRegistry with a JSON file of overrides keyed by
+// "Group.Name" applied, like {"Reading.Blinds": {"Disabled": true}, "Aim.Spin": {"Weight": 0.5}}.
+// The caller installs it as SkillRegistry before any fit starts. Overrides that leave a skill
+// with a negative weight or Min above Max, or no active skill with any weight, are rejected.
+func LoadSkillOverrides(path string) ([]SkillDef, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var overrides map[string]SkillOverride
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	registry := slices.Clone(SkillRegistry)
+	for key, override := range overrides {
+		i := slices.IndexFunc(registry, func(def SkillDef) bool {
+			return def.Group+"."+def.Name == key
+		})
+		if i < 0 {
+			return nil, fmt.Errorf("%s: unknown skill %q", path, key)
+		}
+		def := &registry[i]
+		if override.Disabled != nil {
+			def.Disabled = *override.Disabled
+		}
+		if override.Weight != nil {
+			def.Weight = *override.Weight
+		}
+		if override.Min != nil {
+			def.Min = *override.Min
+		}
+		if override.Max != nil {
+			def.Max = *override.Max
+		}
+		if !(def.Weight >= 0) {
+			return nil, fmt.Errorf("%s: %s has weight %v", path, key, def.Weight)
+		}
+		if !(def.Min <= def.Max) {
+			return nil, fmt.Errorf("%s: %s has Min %v above Max %v", path, key, def.Min, def.Max)
+		}
+	}
+	weightSum := 0.0
+	for _, def := range registry {
+		if !def.Disabled {
+			weightSum += def.Weight
+		}
+	}
+	if weightSum == 0 {
+		return nil, fmt.Errorf("%s: no active skill has any weight", path)
+	}
+	return registry, nil
 }
 
-// test at runtime lol :D
-func init() {
-	vector := [skillCount]float64{}
-	for i := range skillCount {
-		vector[i] = float64(i)
+// ActiveSkills are the skills of SkillRegistry that aren't disabled, in registry order.
+// Skill vectors are indexed like it. A fit takes it once and passes it down.
+func ActiveSkills() []SkillDef {
+	active := make([]SkillDef, 0, len(SkillRegistry))
+	for _, def := range SkillRegistry {
+		if !def.Disabled {
+			active = append(active, def)
+		}
 	}
+	return active
+}
 
-	skills := VectorToSkills(vector)
-	vector1 := SkillsToVector(skills)
-	if vector1 != vector {
-		panic("aaa")
+func (skills Skills) PP(active []SkillDef) float64 {
+	values := make([]float64, len(active))
+	weights := make([]float64, len(active))
+	for i, def := range active {
+		values[i] = def.Get(skills)
+		weights[i] = def.Weight
 	}
+	return PowAvg(values, weights, 2)
+}
+
+// SkillContribution is how much one skill drives the pp of a play.
+type SkillContribution struct {
+	Group string
+	Name  string
+	Value float64
 
-	if unsafe.Sizeof(skills) != skillCount*8 {
-		panic(fmt.Sprintf("%d %d", unsafe.Sizeof(skills), skillCount))
+	Share      float64 // of the weighted sum PowAvg averages
+	PPAtFloor  float64 // pp with this skill at its Min and the others as fitted
+	MarginalPP float64 // pp lost by setting this skill to its floor
+}
+
+// Breakdown returns the contribution of every active skill, the biggest share first.
+func (skills Skills) Breakdown(active []SkillDef) []SkillContribution {
+	pp := skills.PP(active)
+	total := 0.0
+	for _, def := range active {
+		total += def.Weight * math.Pow(def.Get(skills), 2)
+	}
+	breakdown := make([]SkillContribution, 0, len(active))
+	for _, def := range active {
+		value := def.Get(skills)
+		floor := skills
+		def.Set(&floor, def.Min)
+		ppAtFloor := floor.PP(active)
+		breakdown = append(breakdown, SkillContribution{
+			Group:      def.Group,
+			Name:       def.Name,
+			Value:      value,
+			Share:      def.Weight * math.Pow(value, 2) / total,
+			PPAtFloor:  ppAtFloor,
+			MarginalPP: pp - ppAtFloor,
+		})
 	}
+	slices.SortStableFunc(breakdown, func(a, b SkillContribution) int {
+		return cmp.Compare(b.Share, a.Share)
+	})
+	return breakdown
 }
 
-func (skills Skills) PP() float64 {
-	vec := SkillsToVector(skills)
-	return PowAvg(
-		vec[:],
-		2,
-	)
+// PowAvg is the weighted power mean of nums, 0 when no weight is left.
+func PowAvg(nums []float64, weights []float64, pow float64) float64 {
+	sum, weightSum := 0.0, 0.0
+	for i, num := range nums {
+		sum += weights[i] * math.Pow(num, pow)
+		weightSum += weights[i]
+	}
+	if weightSum == 0 {
+		return 0
+	}
+	return math.Pow(sum/weightSum, 1/pow)
 }
 
-func PowAvg(nums []float64, pow float64) float64 {
-	sum := 0.0
-	for _, num := range nums {
-		sum += math.Pow(num, pow)
+func SkillsToVector(active []SkillDef, skills Skills) []float64 {
+	vector := make([]float64, len(active))
+	for i, def := range active {
+		vector[i] = def.Get(skills)
 	}
-	return math.Pow(sum/float64(len(nums)), 1/pow)
+	return vector
 }
 
-func SkillsToVector(v Skills) [skillCount]float64 {
-	return *(*[skillCount]float64)(unsafe.Pointer(&v))
+// VectorToSkills fills the active skills from the vector and pins the disabled ones to their Max.
+func VectorToSkills(active []SkillDef, vector []float64) Skills {
+	var skills Skills
+	for _, def := range SkillRegistry {
+		if def.Disabled {
+			def.Set(&skills, def.Max)
+		}
+	}
+	for i, def := range active {
+		def.Set(&skills, vector[i])
+	}
+	return skills
 }
 
-func VectorToSkills(v [skillCount]float64) Skills {
-	return *(*Skills)(unsafe.Pointer(&v))
+// MarshalJSON writes the active skills by group, in registry order.
+func (skills Skills) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	group := ""
+	for _, def := range ActiveSkills() {
+		if def.Group != group {
+			if group != "" {
+				buf.WriteString("},")
+			}
+			group = def.Group
+			fmt.Fprintf(&buf, "%q:{", group)
+		} else {
+			buf.WriteByte(',')
+		}
+		value, err := json.Marshal(def.Get(skills))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, "%q:%s", def.Name, value)
+	}
+	if group != "" {
+		buf.WriteByte('}')
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
 }
 
 type AimSkills struct {
 	DistancePrecision float64 // aiming correct distance towards the object
 	AnglePrecision    float64 // aiming correct angle towards the object
 	Spin              float64 // spinners
+	SliderTracking    float64 // following fast and curvy slider paths
 }
 
 type TappingSkills struct {
 	Accuracy    float64 // high od
 	BurstSpeed  float64 // high bpm
 	StreamSpeed float64 // high bpm
-	HoldSliders float64 // ability to hold sliderss
+
+	StaminaStream  float64 // chains of circles
+	StaminaControl float64 // chains of bursts
+	StaminaSingles float64 // chains of jumps
 }
 
 type ReadingSkills struct {
-	LowAr float64
+	LowAr         float64
+	HiddenReading float64 // hitting objects that already faded out
+	Flashlight    float64 // memorizing objects hidden by flashlight
+	Traceable     float64 // aiming by the approach circle alone
+	Blinds        float64 // playing near the top and bottom edges behind blinds
+}
+
+type RhythmSkills struct {
+	Singles float64 // jumps
+	Doubles float64 // doubles
+	Triples float64 // triples
+	Quads   float64 // quads
+	Quints  float64 // quints
+
+	EvenStream float64 // continue tapping after an even numbered stream
+	OddStream  float64 // continue tapping after an odd numbered stream
+
+	// the first click after a burst of 2 to maxBurstLength, by the parity of the burst and
+	// whether the click is a single or starts another burst
+	EvenBurstToSingle    float64
+	OddBurstToSingle     float64
+	EvenBurstToOddBurst  float64
+	EvenBurstToEvenBurst float64
+	OddBurstToOddBurst   float64
+	OddBurstToEvenBurst  float64
+
+	Complexity float64 // changing between 1/4, 1/3 and unsnapped rhythms
 }
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// every float64 field of Skills needs exactly one registry entry
+func TestSkillRegistryCoversSkills(t *testing.T) {
+	var skills Skills
+	fields := 0
+	root := reflect.ValueOf(&skills).Elem()
+	for i := range root.NumField() {
+		group := root.Field(i)
+		for j := range group.NumField() {
+			fields++
+			group.Field(j).SetFloat(float64(fields))
+		}
+	}
+	if len(SkillRegistry) != fields {
+		t.Fatalf("%d skills, %d registry entries", fields, len(SkillRegistry))
+	}
+	seen := make(map[float64]string)
+	for _, def := range SkillRegistry {
+		value := def.Get(skills)
+		if other, ok := seen[value]; ok {
+			t.Errorf("%s and %s share a field", other, def.Name)
+		}
+		seen[value] = def.Name
+		field := root.FieldByName(def.Group).FieldByName(def.Name)
+		if !field.IsValid() || field.Float() != value {
+			t.Errorf("%s.%s doesn't match its field", def.Group, def.Name)
+		}
+	}
+}
+
+func TestDisabledSkill(t *testing.T) {
+	registry := SkillRegistry
+	defer func() { SkillRegistry = registry }()
+	SkillRegistry = append([]SkillDef(nil), registry...)
+	SkillRegistry[0].Disabled = true
+
+	vector := make([]float64, len(SkillRegistry)-1)
+	for i := range vector {
+		vector[i] = 100
+	}
+	active := ActiveSkills()
+	skills := VectorToSkills(active, vector)
+	if skills.Aim.DistancePrecision != SkillRegistry[0].Max || skills.Aim.AnglePrecision != 100 {
+		t.Errorf("got %+v", skills.Aim)
+	}
+	if pp := skills.PP(active); math.Abs(pp-100) > 1e-9 {
+		t.Errorf("a disabled skill counts for pp: %v", pp)
+	}
+	data, err := json.Marshal(skills)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "DistancePrecision") || !strings.HasPrefix(string(data), `{"Aim":{"AnglePrecision":100,`) {
+		t.Errorf("got %s", data)
+	}
+}
+
+func TestLoadSkillOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "skills.json")
+	load := func(overrides string) ([]SkillDef, error) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(overrides), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return LoadSkillOverrides(path)
+	}
+
+	before := slices.Clone(SkillRegistry)
+	registry, err := load(`{"Reading.Blinds": {"Disabled": true}, "Aim.AnglePrecision": {"Weight": 0.5, "Min": 10}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, def := range registry {
+		switch def.Group + "." + def.Name {
+		case "Reading.Blinds":
+			if !def.Disabled || def.Weight != 1 {
+				t.Errorf("Blinds: %+v", def)
+			}
+		case "Aim.AnglePrecision":
+			if def.Disabled || def.Weight != 0.5 || def.Min != 10 {
+				t.Errorf("AnglePrecision: %+v", def)
+			}
+		}
+	}
+	for i, def := range SkillRegistry {
+		if def.Disabled != before[i].Disabled || def.Weight != before[i].Weight || def.Min != before[i].Min {
+			t.Errorf("the overrides leaked into SkillRegistry: %+v", def)
+		}
+	}
+
+	all := map[string]SkillOverride{}
+	disabled := true
+	for _, def := range SkillRegistry {
+		all[def.Group+"."+def.Name] = SkillOverride{Disabled: &disabled}
+	}
+	allDisabled, err := json.Marshal(all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, overrides := range map[string]string{
+		"unknown skill":   `{"Aim.Nope": {"Disabled": true}}`,
+		"negative weight": `{"Aim.Spin": {"Weight": -1}}`,
+		"Min above Max":   `{"Aim.Spin": {"Min": 10, "Max": 5}}`,
+		"all disabled":    string(allDisabled),
+	} {
+		if _, err := load(overrides); err == nil {
+			t.Errorf("%s loaded", name)
+		}
+	}
+}
+
+func TestPowAvgWithoutWeight(t *testing.T) {
+	if got := PowAvg([]float64{100, 200}, []float64{0, 0}, 2); got != 0 {
+		t.Errorf("got %v", got)
+	}
+	if got := PowAvg(nil, nil, 2); got != 0 {
+		t.Errorf("empty: got %v", got)
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	active := ActiveSkills()
+	vector := make([]float64, len(active))
+	for i := range vector {
+		vector[i] = 100
+	}
+	vector[0] = 1000
+	skills := VectorToSkills(active, vector)
+
+	breakdown := skills.Breakdown(active)
+	if len(breakdown) != len(vector) || breakdown[0].Name != "DistancePrecision" {
+		t.Fatalf("the biggest skill should come first: %+v", breakdown[0])
+	}
+	shares := 0.0
+	for _, c := range breakdown {
+		shares += c.Share
+		if c.MarginalPP <= 0 || !closeTo(c.PPAtFloor+c.MarginalPP, skills.PP(active)) {
+			t.Errorf("%s: %+v", c.Name, c)
+		}
+	}
+	if !closeTo(shares, 1) {
+		t.Errorf("shares sum to %v", shares)
+	}
+}
+
+// A fitted profile of a plain play: strong aim, decent tapping, no reading mods. Reweighting or
+// adding skills moves its pp; when that is intended, update want.
+func TestFixedProfilePP(t *testing.T) {
+	var skills Skills
+	for _, def := range SkillRegistry {
+		value := map[string]float64{"Aim": 800, "Tapping": 500, "Reading": def.Min, "Rhythm": 300}[def.Group]
+		switch def.Name {
+		case "Accuracy":
+			value = 600
+		case "StaminaStream", "StaminaControl", "StaminaSingles":
+			value = 400
+		}
+		def.Set(&skills, value)
+	}
+	const want = 506.17
+	if got := skills.PP(ActiveSkills()); math.Abs(got-want) > 0.01*want {
+		t.Errorf("got %v pp, want %v within 1%%", got, want)
+	}
+
+	// each family of narrower skills weighs as much as one broad skill
+	families := map[string]float64{}
+	for _, def := range SkillRegistry {
+		switch {
+		case strings.HasPrefix(def.Name, "Stamina"):
+			families["stamina"] += def.Weight
+		case def.Group == "Rhythm" && def.Name != "Complexity":
+			families["burst"] += def.Weight
+		}
+	}
+	for family, weight := range families {
+		if math.Abs(weight-1) > 1e-9 {
+			t.Errorf("%s skills weigh %v together, want 1", family, weight)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"math"
 	"ppv3/dotosu"
+	"slices"
+	"sort"
 )
 
 // === constants chosen to mirror osu!lazer PathApproximator ===
@@ -14,7 +16,8 @@ const (
 
 // ApproximateSliderPath returns a polyline approximation for a slider path.
 // Points are absolute playfield coordinates; first element is the slider head.
-// Duplicates and zero-length steps are removed.
+// Duplicates and zero-length steps are removed, and the path is trimmed or extended
+// to the declared slider length like lazer's SliderPath.ExpectedDistance.
 func ApproximateSliderPath(slider dotosu.Slider) []Vec {
 	path := slider.Path
 	var poly []Vec
@@ -51,13 +54,18 @@ func ApproximateSliderPath(slider dotosu.Slider) []Vec {
 			appendMany(approximateBezier(v))
 		}
 
-	default: // Bezier with red-anchor segmentation
+	default: // Bezier and B-spline with red-anchor segmentation
 		for si, seg := range path.Segments {
 			v := toVecs(seg.Points)
 			if len(v) < 2 {
 				continue
 			}
-			pts := approximateBezier(v)
+			var pts []Vec
+			if path.Type == dotosu.PathBSpline {
+				pts = approximateBSpline(v, path.Degree)
+			} else {
+				pts = approximateBezier(v)
+			}
 			// Avoid duplicating the shared point between consecutive Bezier segments.
 			if si > 0 && len(pts) > 0 && len(poly) > 0 && almostEq(poly[len(poly)-1], pts[0]) {
 				pts = pts[1:]
@@ -66,11 +74,71 @@ func ApproximateSliderPath(slider dotosu.Slider) []Vec {
 		}
 	}
 
+	// checked before the duplicate is compacted away below
+	endDuplicated := endPointDuplicated(path)
 	// compact collinear/zero-length steps
 	poly = dedupeCollinear(poly)
+	// lazer ignores a missing or non-positive length and keeps the calculated one
+	if slider.Length > 0 {
+		poly = normalizePathLength(poly, slider.Length, endDuplicated)
+	}
 	return poly
 }
 
+// endPointDuplicated reports whether the last two control points are equal. A red anchor on
+// the end splits it off into a segment of its own.
+func endPointDuplicated(path dotosu.SliderPath) bool {
+	if len(path.Segments) == 0 {
+		return false
+	}
+	points := path.Segments[len(path.Segments)-1].Points
+	if len(points) == 1 {
+		return len(path.Segments) > 1
+	}
+	return len(points) >= 2 && points[len(points)-1] == points[len(points)-2]
+}
+
+// normalizePathLength mirrors lazer's SliderPath.calculateLength: points past the expected
+// distance are dropped and the last segment is shortened or extended along its direction.
+func normalizePathLength(poly []Vec, expected float64, endDuplicated bool) []Vec {
+	if len(poly) < 2 {
+		return poly
+	}
+	cumulative := cumulativeLengths(poly)
+	if cumulative[len(cumulative)-1] == expected {
+		return poly
+	}
+	// like stable, a path ending on a duplicated point is never extended
+	if endDuplicated && expected > cumulative[len(cumulative)-1] {
+		return poly
+	}
+	poly = slices.Clone(poly)
+	// the last length is always incorrect
+	total := cumulative[len(cumulative)-1]
+	cumulative = cumulative[:len(cumulative)-1]
+	end := len(poly) - 1
+	if total > expected {
+		for len(cumulative) > 0 && cumulative[len(cumulative)-1] >= expected {
+			cumulative = cumulative[:len(cumulative)-1]
+			poly = poly[:end]
+			end--
+		}
+	}
+	dir := norm(sub(poly[end], poly[end-1]))
+	remaining := expected - cumulative[len(cumulative)-1]
+	poly[end] = Vec{poly[end-1].X + dir.X*remaining, poly[end-1].Y + dir.Y*remaining}
+	return poly
+}
+
+// cumulativeLengths returns the path length up to every point of poly, starting with 0.
+func cumulativeLengths(poly []Vec) []float64 {
+	out := make([]float64, len(poly))
+	for i := 1; i < len(poly); i++ {
+		out[i] = out[i-1] + dist(poly[i-1], poly[i])
+	}
+	return out
+}
+
 // --- Bezier (adaptive subdivision, identical strategy to lazer) ---
 
 func approximateBezier(cp []Vec) []Vec {
@@ -154,6 +222,52 @@ func bezierSubdivide(cp []Vec) (left, right []Vec) {
 	return left, right
 }
 
+// --- B-spline (split into Bezier pieces, like lazer's BSplineToPiecewiseLinear) ---
+
+func approximateBSpline(cp []Vec, degree int) []Vec {
+	var out []Vec
+	for i, piece := range bSplineToBezier(cp, degree) {
+		pts := approximateBezier(piece)
+		// each piece starts where the previous one ended
+		if i > 0 {
+			pts = pts[1:]
+		}
+		out = append(out, pts...)
+	}
+	return out
+}
+
+// bSplineToBezier converts a clamped uniform B-spline into Bezier pieces
+// by inserting every inner knot degree-1 times (Boehm's algorithm).
+func bSplineToBezier(cp []Vec, degree int) [][]Vec {
+	pointCount := len(cp) - 1
+	// with too few points the fit is ambiguous; lazer lowers the degree instead of failing
+	degree = mini(degree, pointCount)
+	points := slices.Clone(cp)
+	if degree == pointCount {
+		return [][]Vec{points}
+	}
+
+	var out [][]Vec
+	for i := 0; i < pointCount-degree; i++ {
+		sub := make([]Vec, degree+1)
+		sub[0] = points[i]
+		for j := 0; j < degree-1; j++ {
+			sub[j+1] = points[i+1]
+			for k := 1; k < degree-j; k++ {
+				l := float64(mini(k, pointCount-degree-i))
+				points[i+k] = Vec{
+					X: (l*points[i+k].X + points[i+k+1].X) / (l + 1),
+					Y: (l*points[i+k].Y + points[i+k+1].Y) / (l + 1),
+				}
+			}
+		}
+		sub[degree] = points[i+1]
+		out = append(out, sub)
+	}
+	return append(out, points[pointCount-degree:])
+}
+
 // --- Catmull-Rom (uniform, with lazer's detail count) ---
 
 func approximateCatmull(pts []Vec) []Vec {
@@ -334,6 +448,9 @@ func mini(a, b int) int {
 	}
 	return b
 }
+func clampInt(x, lo, hi int) int {
+	return maxi(lo, mini(x, hi))
+}
 func maxi(a, b int) int {
 	if a > b {
 		return a
@@ -341,36 +458,102 @@ func maxi(a, b int) int {
 	return b
 }
 
-func GetSliderPosition(poly []Vec, progress float64) Vec {
-	for i := 1; i < len(poly); i++ {
-		dir := Vec{
-			X: poly[i].X - poly[i-1].X,
-			Y: poly[i].Y - poly[i-1].Y,
-		}
-		l := math.Hypot(dir.X, dir.Y)
-		if progress <= l {
-			return Vec{
-				X: poly[i-1].X + dir.X*progress/l,
-				Y: poly[i-1].Y + dir.Y*progress/l,
-			}
-		} else {
-			progress -= l
+// SliderCurve is an approximated slider path together with its arc-length table,
+// so positions along it are found by binary search.
+type SliderCurve struct {
+	Points  []Vec
+	Lengths []float64 // path length up to each point, Lengths[0] == 0
+}
+
+func NewSliderCurve(slider dotosu.Slider) *SliderCurve {
+	points := ApproximateSliderPath(slider)
+	return &SliderCurve{
+		Points:  points,
+		Lengths: cumulativeLengths(points),
+	}
+}
+
+// Length is the path length; it equals the declared slider length when the map has one.
+func (c *SliderCurve) Length() float64 {
+	if len(c.Lengths) == 0 {
+		return 0
+	}
+	return c.Lengths[len(c.Lengths)-1]
+}
+
+// segmentAt returns the index i of the segment [i-1, i] holding distance, clamped to the path.
+func (c *SliderCurve) segmentAt(distance float64) int {
+	i := sort.SearchFloat64s(c.Lengths, distance)
+	return clampInt(i, 1, len(c.Points)-1)
+}
+
+// PositionAt returns the point at progress (0 = head, 1 = end) along the path, clamped to its ends.
+func (c *SliderCurve) PositionAt(progress float64) Vec {
+	if len(c.Points) < 2 {
+		if len(c.Points) == 0 {
+			return Vec{}
 		}
+		return c.Points[0]
 	}
-	if len(poly) < 2 {
-		panic("wtf")
-		//return Vec{poly[0].X + progress, poly[0].Y}
+	distance := clamp(progress, 0, 1) * c.Length()
+	i := c.segmentAt(distance)
+	d0, d1 := c.Lengths[i-1], c.Lengths[i]
+	p0, p1 := c.Points[i-1], c.Points[i]
+	if math.Abs(d1-d0) < 1e-7 {
+		return p0
 	}
-	from := poly[len(poly)-1]
-	dir := Vec{
-		X: poly[len(poly)-1].X - poly[len(poly)-2].X,
-		Y: poly[len(poly)-1].Y - poly[len(poly)-2].Y,
+	w := (distance - d0) / (d1 - d0)
+	return Vec{
+		X: p0.X + (p1.X-p0.X)*w,
+		Y: p0.Y + (p1.Y-p0.Y)*w,
 	}
+}
 
-	l := math.Hypot(dir.X, dir.Y)
+// DirectionAt returns the unit direction of travel at progress, zero for a single-point path.
+func (c *SliderCurve) DirectionAt(progress float64) Vec {
+	if len(c.Points) < 2 {
+		return Vec{}
+	}
+	i := c.segmentAt(clamp(progress, 0, 1) * c.Length())
+	return norm(sub(c.Points[i], c.Points[i-1]))
+}
 
-	return Vec{
-		X: from.X + dir.X*progress/l,
-		Y: from.Y + dir.Y*progress/l,
+// ballProgress returns where the ball is on the path (0 = head, 1 = end) at time t after the slider start.
+func ballProgress(spanDuration float64, spans int, t float64) float64 {
+	if spanDuration <= 0 {
+		return 0
+	}
+	span := clampInt(int(t/spanDuration), 0, maxi(1, spans)-1)
+	progress := clamp(t/spanDuration-float64(span), 0, 1)
+	if span%2 == 1 {
+		progress = 1 - progress
+	}
+	return progress
+}
+
+const followStep = 5.0 // ms between samples when integrating the ball movement
+
+// FollowBetween integrates the ball movement between two times (relative to the slider start):
+// the path length it covers and the total angle it turns, reversals included.
+func (c *SliderCurve) FollowBetween(spanDuration float64, spans int, from, to float64) (distance, turning float64) {
+	steps := maxi(1, int(math.Ceil((to-from)/followStep)))
+	prev := c.PositionAt(ballProgress(spanDuration, spans, from))
+	var prevDir Vec
+	for i := 1; i <= steps; i++ {
+		t := from + (to-from)*float64(i)/float64(steps)
+		pos := c.PositionAt(ballProgress(spanDuration, spans, t))
+		step := sub(pos, prev)
+		prev = pos
+		l := math.Hypot(step.X, step.Y)
+		if l == 0 {
+			continue
+		}
+		distance += l
+		dir := Vec{step.X / l, step.Y / l}
+		if prevDir != (Vec{}) {
+			turning += math.Acos(clamp(dot(prevDir, dir), -1, 1))
+		}
+		prevDir = dir
 	}
+	return distance, turning
 }
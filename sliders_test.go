@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"ppv3/dotosu"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const sliderFixturesPath = "testdata/sliders.json"
+
+// sliderFixture is one slider of the golden corpus. The expected values are derived by hand,
+// not recorded from the implementation: lines and circle arcs exactly, Bézier, Catmull and
+// B-spline curves from their polynomial form with the arc length integrated numerically, and
+// times by lazer's SliderEventGenerator rules. Note says how.
+type sliderFixture struct {
+	Name             string
+	Note             string
+	HitObject        string // a [HitObjects] line
+	SliderMultiplier float64
+	TickRate         float64
+	BeatLength       float64
+	SliderVelocity   float64
+
+	// how far positions may be off; flattening a curve into a polyline moves points along it
+	Tolerance float64
+	Positions []Vec // at fixtureProgress
+	Actions   []fixtureAction
+}
+
+type fixtureAction struct {
+	Time       float64
+	Pos        Vec
+	SliderTick bool `json:",omitempty"`
+	SliderEnd  bool `json:",omitempty"`
+}
+
+var fixtureProgress = []float64{0, 0.25, 0.5, 0.75, 1}
+
+func (f sliderFixture) beatmap(t *testing.T) *dotosu.Beatmap {
+	t.Helper()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "osu file format v14\n\n[Difficulty]\nCircleSize:4\nOverallDifficulty:8\nApproachRate:9\n")
+	fmt.Fprintf(&sb, "SliderMultiplier:%v\nSliderTickRate:%v\n\n", f.SliderMultiplier, f.TickRate)
+	fmt.Fprintf(&sb, "[TimingPoints]\n0,%v,4,2,0,100,1,0\n", f.BeatLength)
+	if f.SliderVelocity != 1 {
+		fmt.Fprintf(&sb, "0,%v,4,2,0,100,0,0\n", -100/f.SliderVelocity)
+	}
+	fmt.Fprintf(&sb, "\n[HitObjects]\n%s\n", f.HitObject)
+	beatmap, err := dotosu.DecodeWithOptions(strings.NewReader(sb.String()), dotosu.DecodeOptions{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return beatmap
+}
+
+func (f sliderFixture) compute(t *testing.T) sliderFixture {
+	t.Helper()
+	beatmap := f.beatmap(t)
+	slider := beatmap.HitObjects[0].(dotosu.Slider)
+
+	curve := NewSliderCurve(slider)
+	f.Positions = nil
+	for _, progress := range fixtureProgress {
+		f.Positions = append(f.Positions, curve.PositionAt(progress))
+	}
+
+	mods := Modifiers{Rate: 1}
+	actions, err := ConvertBeatmapToActions(GetBeatmapConstants(beatmap, mods), beatmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Actions = nil
+	for _, action := range actions {
+		f.Actions = append(f.Actions, fixtureAction{
+			Time:       action.Time,
+			Pos:        action.Pos,
+			SliderTick: action.SliderTick,
+			SliderEnd:  action.SliderEnd,
+		})
+	}
+	return f
+}
+
+func loadSliderFixtures(t *testing.T) []sliderFixture {
+	t.Helper()
+	data, err := os.ReadFile(sliderFixturesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixtures []sliderFixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatal(err)
+	}
+	return fixtures
+}
+
+func TestSliderFixtures(t *testing.T) {
+	for _, want := range loadSliderFixtures(t) {
+		t.Run(want.Name, func(t *testing.T) {
+			got := want.compute(t)
+			assertVecsWithin(t, "positions", got.Positions, want.Positions, want.Tolerance)
+
+			if len(got.Actions) != len(want.Actions) {
+				t.Fatalf("got %d actions, want %d: %+v", len(got.Actions), len(want.Actions), got.Actions)
+			}
+			for i := range want.Actions {
+				g, w := got.Actions[i], want.Actions[i]
+				if !closeTo(g.Time, w.Time) || !withinVec(g.Pos, w.Pos, want.Tolerance) || g.SliderTick != w.SliderTick || g.SliderEnd != w.SliderEnd {
+					t.Errorf("action %d: got %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+// TestSliderPathInvariants checks what holds regardless of the expected values.
+func TestSliderPathInvariants(t *testing.T) {
+	for _, f := range loadSliderFixtures(t) {
+		t.Run(f.Name, func(t *testing.T) {
+			slider := f.beatmap(t).HitObjects[0].(dotosu.Slider)
+			curve := NewSliderCurve(slider)
+			poly := curve.Points
+
+			head := Vec{float64(slider.PosXY.X), float64(slider.PosXY.Y)}
+			if !closeToVec(poly[0], head) {
+				t.Errorf("path starts at %v, head is %v", poly[0], head)
+			}
+			if math.Abs(curve.Length()-slider.Length) > 1e-6 {
+				t.Errorf("path length %v, declared %v", curve.Length(), slider.Length)
+			}
+			if end := curve.PositionAt(1.5); !closeToVec(end, poly[len(poly)-1]) {
+				t.Errorf("position past the end %v, path ends at %v", end, poly[len(poly)-1])
+			}
+			// the direction at the end points along the last segment
+			last := norm(sub(poly[len(poly)-1], poly[len(poly)-2]))
+			if dir := curve.DirectionAt(1); !closeToVec(dir, last) {
+				t.Errorf("direction at the end %v, want %v", dir, last)
+			}
+		})
+	}
+}
+
+func assertVecsWithin(t *testing.T, what string, got, want []Vec, tolerance float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: got %d points, want %d", what, len(got), len(want))
+		return
+	}
+	for i := range want {
+		if !withinVec(got[i], want[i], tolerance) {
+			t.Errorf("%s[%d]: got %v, want %v", what, i, got[i], want[i])
+		}
+	}
+}
+
+func withinVec(a, b Vec, tolerance float64) bool {
+	return math.Abs(a.X-b.X) <= tolerance && math.Abs(a.Y-b.Y) <= tolerance
+}
+
+func closeTo(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func closeToVec(a, b Vec) bool { return closeTo(a.X, b.X) && closeTo(a.Y, b.Y) }
+
+// catmullMap is a synthetic map of long Catmull sliders, the most expensive path type to approximate.
+func catmullMap(b *testing.B, sliders int) *dotosu.Beatmap {
+	b.Helper()
+	var sb strings.Builder
+	sb.WriteString("osu file format v14\n\n[Difficulty]\nCircleSize:4\nOverallDifficulty:8\nApproachRate:9\nSliderMultiplier:1.8\nSliderTickRate:4\n\n")
+	sb.WriteString("[TimingPoints]\n0,300,4,2,0,100,1,0\n\n[HitObjects]\n")
+	for i := range sliders {
+		sb.WriteString(fmt.Sprintf("64,64,%d,2,0,C", 1000+i*4000))
+		for j := 1; j <= 12; j++ {
+			sb.WriteString(fmt.Sprintf("|%d:%d", 64+j*32, 64+(j%2)*240))
+		}
+		sb.WriteString(",2,900\n")
+	}
+	beatmap, err := dotosu.Decode(strings.NewReader(sb.String()))
+	if err != nil {
+		b.Fatal(err)
+	}
+	return beatmap
+}
+
+func BenchmarkSliderCurvePositionAt(b *testing.B) {
+	curve := NewSliderCurve(catmullMap(b, 1).HitObjects[0].(dotosu.Slider))
+	for i := 0; b.Loop(); i++ {
+		curve.PositionAt(float64(i%1000) / 1000)
+	}
+}
+
+func BenchmarkConvertBeatmapToActionsCatmull(b *testing.B) {
+	beatmap := catmullMap(b, 200)
+	mapConstants := GetBeatmapConstants(beatmap, Modifiers{Rate: 1})
+	for b.Loop() {
+		if _, err := ConvertBeatmapToActions(mapConstants, beatmap); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestFollowBetween(t *testing.T) {
+	f := sliderFixture{HitObject: "100,100,1000,2,0,L|300:100,2,200", SliderMultiplier: 1, TickRate: 1, BeatLength: 500, SliderVelocity: 1}
+	slider := f.beatmap(t).HitObjects[0].(dotosu.Slider)
+	curve := NewSliderCurve(slider)
+
+	// there and back along a straight line: the full length twice and one reversal
+	distance, turning := curve.FollowBetween(1000, 2, 0, 2000)
+	if !closeTo(distance, 400) || !closeTo(turning, math.Pi) {
+		t.Errorf("got distance %v, turning %v", distance, turning)
+	}
+	distance, turning = curve.FollowBetween(1000, 2, 250, 750)
+	if !closeTo(distance, 100) || turning != 0 {
+		t.Errorf("within a span: distance %v, turning %v", distance, turning)
+	}
+}
+
+func TestSliderPathDuplicatedEnd(t *testing.T) {
+	tests := []struct {
+		hitObject string
+		length    float64
+	}{
+		// too short for the declared length, but the last point repeats
+		{"100,100,0,2,0,L|200:100|200:100,1,150", 100},
+		{"100,100,0,2,0,B|150:100|200:100|200:100,1,150", 100},
+		// still cut when too long
+		{"100,100,0,2,0,L|200:100|200:100,1,50", 50},
+		{"100,100,0,2,0,L|200:100,1,150", 150},
+	}
+	for _, test := range tests {
+		f := sliderFixture{HitObject: test.hitObject, SliderMultiplier: 1, TickRate: 1, BeatLength: 500, SliderVelocity: 1}
+		curve := NewSliderCurve(f.beatmap(t).HitObjects[0].(dotosu.Slider))
+		if !closeTo(curve.Length(), test.length) {
+			t.Errorf("%s: length %v, want %v", test.hitObject, curve.Length(), test.length)
+		}
+	}
+}
+
+func TestZeroLengthSlider(t *testing.T) {
+	f := sliderFixture{HitObject: "100,100,1000,2,0,L|100:100,3,0", SliderMultiplier: 1, TickRate: 1, BeatLength: 500, SliderVelocity: 1}
+	beatmap := f.beatmap(t)
+	actions, err := ConvertBeatmapToActions(GetBeatmapConstants(beatmap, Modifiers{Rate: 1}), beatmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 || !actions[0].Circle {
+		t.Errorf("got %d actions, want the head judged alone", len(actions))
+	}
+}
+
+// The overlap lint quarantines exactly the maps whose actions are too close.
+func TestOverlapLintMatchesActions(t *testing.T) {
+	// one span of 1000ms with a tick at 500 and the legacy last tick at 964
+	const slider = "100,100,0,2,0,L|300:100,1,200\n"
+	overlap := dotosu.DefaultLintRules[slices.IndexFunc(dotosu.DefaultLintRules, func(rule dotosu.LintRule) bool {
+		return rule.Name == "overlap"
+	})]
+	for _, c := range []struct {
+		name, next string
+		tooClose   bool
+	}{
+		{"on the tick", "200,200,500,1,0", true},
+		{"on the legacy last tick", "200,200,964,1,0", true},
+		{"slider on the legacy last tick", "200,200,964,2,0,L|300:200,1,100", true},
+		{"between the legacy last tick and the tail", "200,200,980,1,0", false},
+		{"after the tail", "200,200,1200,1,0", false},
+		{"spinner middle after the legacy last tick", "256,192,900,12,0,1100", false},
+	} {
+		f := sliderFixture{HitObject: slider + c.next, SliderMultiplier: 1, TickRate: 1, BeatLength: 500, SliderVelocity: 1}
+		beatmap := f.beatmap(t)
+		_, err := ConvertBeatmapToActions(GetBeatmapConstants(beatmap, Modifiers{Rate: 1}), beatmap)
+		if (err != nil) != c.tooClose {
+			t.Errorf("%s: actions error %v", c.name, err)
+		}
+		findings := beatmap.Lint(overlap)
+		if got := dotosu.MaxSeverity(findings) >= dotosu.SeverityError; got != c.tooClose {
+			t.Errorf("%s: lint error %v, want %v:\n%s", c.name, got, c.tooClose, dotosu.FormatFindings(findings))
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import "ppv3/dotosu"
+
+// mirrors osu!lazer OsuBeatmapProcessor
+const stackDistance = 3
+
+// StackObject is the part of a hit object that stacking cares about.
+// Actions in [FirstAction, LastAction) belong to this object and are moved together.
+type StackObject struct {
+	Kind       dotosu.ObjectKind
+	StartTime  float64
+	EndTime    float64
+	Pos        Vec
+	EndPos     Vec // where the slider finishes after all slides
+	PathEndPos Vec // end of the slider path, used by the old format algorithm
+
+	StackHeight int
+
+	FirstAction int
+	LastAction  int
+}
+
+// ApplyStacking calculates stack heights and offsets the actions of every stacked object.
+// Times are expected to be in map time (before rate adjustment).
+func ApplyStacking(
+	mapConstants MapConstants,
+	beatmap *dotosu.Beatmap,
+	objects []StackObject,
+	actions []*Action,
+) {
+	stackThreshold := mapConstants.Preempt * beatmap.General.StackLeniency
+
+	if beatmap.FormatVersion >= 6 {
+		applyStacking(objects, stackThreshold)
+	} else {
+		applyStackingOld(objects, stackThreshold)
+	}
+
+	scale := mapConstants.CircleRadius / 64
+	for _, object := range objects {
+		if object.StackHeight == 0 {
+			continue
+		}
+		offset := float64(object.StackHeight) * scale * -6.4
+		for i := object.FirstAction; i < object.LastAction; i++ {
+			actions[i].Pos.X += offset
+			actions[i].Pos.Y += offset
+		}
+	}
+}
+
+func applyStacking(objects []StackObject, stackThreshold float64) {
+	for i := len(objects) - 1; i > 0; i-- {
+		n := i
+		objectI := &objects[i]
+
+		if objectI.StackHeight != 0 || objectI.Kind == dotosu.KindSpinner {
+			continue
+		}
+
+		switch objectI.Kind {
+		case dotosu.KindCircle:
+			for n--; n >= 0; n-- {
+				objectN := &objects[n]
+				if objectN.Kind == dotosu.KindSpinner {
+					continue
+				}
+				if objectI.StartTime-objectN.EndTime > stackThreshold {
+					break
+				}
+
+				// a slider ending on top of the stack bumps the notes after it down and right
+				if objectN.Kind == dotosu.KindSlider && Distance(objectN.EndPos, objectI.Pos) < stackDistance {
+					offset := objectI.StackHeight - objectN.StackHeight + 1
+					for j := n + 1; j <= i; j++ {
+						if Distance(objectN.EndPos, objects[j].Pos) < stackDistance {
+							objects[j].StackHeight -= offset
+						}
+					}
+					break
+				}
+
+				if Distance(objectN.Pos, objectI.Pos) < stackDistance {
+					objectN.StackHeight = objectI.StackHeight + 1
+					objectI = objectN
+				}
+			}
+		case dotosu.KindSlider:
+			for n--; n >= 0; n-- {
+				objectN := &objects[n]
+				if objectN.Kind == dotosu.KindSpinner {
+					continue
+				}
+				if objectI.StartTime-objectN.StartTime > stackThreshold {
+					break
+				}
+				if Distance(objectN.EndPos, objectI.Pos) < stackDistance {
+					objectN.StackHeight = objectI.StackHeight + 1
+					objectI = objectN
+				}
+			}
+		}
+	}
+}
+
+// stable behaviour for beatmaps older than v6
+func applyStackingOld(objects []StackObject, stackThreshold float64) {
+	for i := range objects {
+		current := &objects[i]
+		if current.StackHeight != 0 && current.Kind != dotosu.KindSlider {
+			continue
+		}
+
+		startTime := current.EndTime
+		sliderStack := 0
+
+		pos2 := current.Pos
+		if current.Kind == dotosu.KindSlider {
+			pos2 = current.PathEndPos
+		}
+
+		for j := i + 1; j < len(objects); j++ {
+			if objects[j].StartTime-stackThreshold > startTime {
+				break
+			}
+
+			if Distance(objects[j].Pos, current.Pos) < stackDistance {
+				current.StackHeight++
+				startTime = objects[j].StartTime
+			} else if Distance(objects[j].Pos, pos2) < stackDistance {
+				sliderStack++
+				objects[j].StackHeight -= sliderStack
+				startTime = objects[j].StartTime
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"ppv3/dotosu"
+	"strings"
+	"testing"
+)
+
+func circleAt(time, x, y float64) StackObject {
+	pos := Vec{X: x, Y: y}
+	return StackObject{Kind: dotosu.KindCircle, StartTime: time, EndTime: time, Pos: pos, EndPos: pos, PathEndPos: pos}
+}
+
+func sliderAt(start, end float64, from, to Vec) StackObject {
+	return StackObject{Kind: dotosu.KindSlider, StartTime: start, EndTime: end, Pos: from, EndPos: to, PathEndPos: to}
+}
+
+// The expected heights follow lazer's OsuBeatmapProcessor by hand: the new algorithm walks back
+// from the last object, the old one forward from the first.
+func TestApplyStacking(t *testing.T) {
+	a, b := Vec{X: 100, Y: 100}, Vec{X: 200, Y: 100}
+	tests := []struct {
+		name          string
+		formatVersion int
+		leniency      float64 // of a 600ms preempt
+		objects       []StackObject
+		heights       []int
+	}{
+		{"stack", 14, 0.7, []StackObject{circleAt(0, 100, 100), circleAt(100, 100, 100), circleAt(200, 101, 101)}, []int{2, 1, 0}},
+		// each link of the chain is within the threshold, not the whole chain
+		{"chain", 14, 0.7, []StackObject{circleAt(0, 100, 100), circleAt(400, 100, 100), circleAt(800, 100, 100)}, []int{2, 1, 0}},
+		{"low leniency", 14, 0.2, []StackObject{circleAt(0, 100, 100), circleAt(400, 100, 100), circleAt(800, 100, 100)}, []int{0, 0, 0}},
+		{"apart", 14, 0.7, []StackObject{circleAt(0, 100, 100), circleAt(100, 103, 100)}, []int{0, 0}},
+		// notes on a slider end go down and right
+		{"slider end", 14, 0.7, []StackObject{sliderAt(0, 200, a, b), circleAt(300, 200, 100), circleAt(400, 200, 100)}, []int{0, -1, -2}},
+		{"slider on slider end", 14, 0.7, []StackObject{sliderAt(0, 200, a, b), sliderAt(300, 500, b, a)}, []int{1, 0}},
+		{"spinner between", 14, 0.7, []StackObject{circleAt(0, 100, 100), {Kind: dotosu.KindSpinner, StartTime: 50, EndTime: 60, Pos: CenterPos, EndPos: CenterPos, PathEndPos: CenterPos}, circleAt(100, 100, 100)}, []int{1, 0, 0}},
+		{"old stack", 5, 0.7, []StackObject{circleAt(0, 100, 100), circleAt(100, 100, 100), circleAt(200, 101, 101)}, []int{2, 1, 0}},
+		{"old slider end", 5, 0.7, []StackObject{sliderAt(0, 200, a, b), circleAt(300, 200, 100), circleAt(400, 200, 100)}, []int{0, -1, -2}},
+		// the old algorithm measures from the end of the object before
+		{"old after slider", 5, 0.7, []StackObject{sliderAt(0, 1000, a, b), circleAt(1300, 100, 100)}, []int{1, 0}},
+	}
+	for _, test := range tests {
+		beatmap := &dotosu.Beatmap{FormatVersion: test.formatVersion}
+		beatmap.General.StackLeniency = test.leniency
+		ApplyStacking(MapConstants{Preempt: 600, CircleRadius: 32}, beatmap, test.objects, nil)
+		for i, object := range test.objects {
+			if object.StackHeight != test.heights[i] {
+				t.Errorf("%s: object %d has height %d, want %d", test.name, i, object.StackHeight, test.heights[i])
+			}
+		}
+	}
+}
+
+func TestStackedSliderActions(t *testing.T) {
+	const osu = `osu file format v14
+
+[General]
+StackLeniency: 0.7
+
+[Difficulty]
+CircleSize:5
+OverallDifficulty:8
+ApproachRate:9
+SliderMultiplier:1
+SliderTickRate:2
+
+[TimingPoints]
+0,500,4,2,0,100,1,0
+
+[HitObjects]
+100,100,1000,2,0,L|200:100,1,100
+100,100,1600,1,0
+`
+	beatmap, err := dotosu.Decode(strings.NewReader(osu))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapConstants := GetBeatmapConstants(beatmap, Modifiers{Rate: 1})
+	actions, err := ConvertBeatmapToActions(mapConstants, beatmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// CS5 is a scale of 0.5: the slider is one up the stack, 3.2 up and left with its tick and
+	// legacy last tick, which is 36ms or 7.2 short of the end
+	want := []Vec{{X: 96.8, Y: 96.8}, {X: 146.8, Y: 96.8}, {X: 189.6, Y: 96.8}, {X: 100, Y: 100}}
+	if len(actions) != len(want) {
+		t.Fatalf("got %d actions, want %d", len(actions), len(want))
+	}
+	for i, action := range actions {
+		if !withinVec(action.Pos, want[i], 0.01) {
+			t.Errorf("action %d at %v, want %v", i, action.Pos, want[i])
+		}
+	}
+}
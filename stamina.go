@@ -0,0 +1,74 @@
+package main
+
+import "math"
+
+const (
+	// the load of a click or a jump halves every staminaHalfLife ms
+	staminaHalfLife = 2000
+	// a click this much later than the previous gap ends the stream it was part of
+	streamBreakRatio = 1.8
+)
+
+// PrecalculateStamina sets the tapping and jumping load every action is played under. Each
+// click adds its speed (1 for 1/4 at 300bpm) and the cursor velocity of the jump from the click
+// before, and all of it decays over time, so long deathstreams weigh much more than the same
+// bpm in short bursts. Following a slider is not a jump.
+func PrecalculateStamina(
+	actions []*Action,
+) {
+	var tapLoad, streamLoad, jumpLoad float64
+	var lastClick, lastAim *Action
+	lastGap := math.Inf(1)
+	for _, action := range actions {
+		if lastAim != nil {
+			deltaTime := max(1, action.Time-lastAim.Time)
+			decay := math.Exp2(-deltaTime / staminaHalfLife)
+			tapLoad *= decay
+			streamLoad *= decay
+			jumpLoad *= decay
+		}
+		action.TapLoad = tapLoad
+		action.StreamLoad = streamLoad
+		action.JumpLoad = jumpLoad
+
+		lastAim = action
+
+		if !action.Clickable {
+			continue
+		}
+		gap := math.Inf(1)
+		if lastClick != nil {
+			gap = max(1, action.Time-lastClick.Time)
+		}
+		if gap > lastGap*streamBreakRatio {
+			streamLoad = 0
+		}
+		if lastClick != nil {
+			jumpLoad += Distance(lastClick.Pos, action.Pos) / gap
+		}
+		tapLoad += 50 / gap
+		streamLoad += 50 / gap
+		lastGap = gap
+		lastClick = action
+	}
+}
+
+// StaminaTapError scales the unstable rate of a click made while tired from tapping. Load of
+// the current stream needs stream stamina, load left over from the bursts before it needs control.
+func StaminaTapError(
+	it *PPIter,
+	action *Action,
+) float64 {
+	controlLoad := action.TapLoad - action.StreamLoad
+	return 1 +
+		0.001*action.StreamLoad*action.StreamLoad/it.Skills.Tapping.StaminaStream +
+		0.001*controlLoad*controlLoad/it.Skills.Tapping.StaminaControl
+}
+
+// StaminaAimError scales the aim error of an action made while tired from jumping.
+func StaminaAimError(
+	it *PPIter,
+	action *Action,
+) float64 {
+	return 1 + 0.001*action.JumpLoad*action.JumpLoad/it.Skills.Tapping.StaminaSingles
+}
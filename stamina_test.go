@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// clicks at gap ms apart, in groups of burst with rest ms between the groups
+func tapping(count, burst int, gap, rest float64) []*Action {
+	var actions []*Action
+	time := 0.0
+	for i := range count {
+		if i > 0 {
+			time += gap
+			if i%burst == 0 {
+				time += rest
+			}
+		}
+		actions = append(actions, &Action{Pos: CenterPos, Time: time, Clickable: true, Circle: true})
+	}
+	return actions
+}
+
+func TestStaminaDeathstreamVsBursts(t *testing.T) {
+	stream := tapping(200, 200, 75, 0)
+	bursts := tapping(200, 9, 75, 300)
+	PrecalculateStamina(stream)
+	PrecalculateStamina(bursts)
+
+	last, lastBurst := stream[len(stream)-1], bursts[len(bursts)-1]
+	if last.StreamLoad != last.TapLoad {
+		t.Errorf("a single stream is all stream load: %v of %v", last.StreamLoad, last.TapLoad)
+	}
+	if lastBurst.StreamLoad >= last.StreamLoad/2 {
+		t.Errorf("burst stream load %v, deathstream %v", lastBurst.StreamLoad, last.StreamLoad)
+	}
+	if lastBurst.TapLoad >= last.TapLoad || lastBurst.TapLoad-lastBurst.StreamLoad <= 0 {
+		t.Errorf("burst load %v (stream %v), deathstream %v", lastBurst.TapLoad, lastBurst.StreamLoad, last.TapLoad)
+	}
+}
+
+func TestJumpLoadCountsClicksOnly(t *testing.T) {
+	actions := []*Action{
+		{Pos: Vec{X: 0, Y: 0}, Time: 0, Clickable: true},
+		// the slider end, 300px away
+		{Pos: Vec{X: 300, Y: 0}, Time: 200, SliderEnd: true, SliderLast: true},
+		{Pos: Vec{X: 100, Y: 0}, Time: 400, Clickable: true, Circle: true},
+		{Pos: Vec{X: 100, Y: 0}, Time: 600, Clickable: true, Circle: true},
+	}
+	PrecalculateStamina(actions)
+	if actions[2].JumpLoad != 0 {
+		t.Errorf("following the slider added jump load %v", actions[2].JumpLoad)
+	}
+	// 100px from the slider head in 400ms, halved by the 200ms since
+	if want := 0.25 * math.Exp2(-200.0/staminaHalfLife); !closeTo(actions[3].JumpLoad, want) {
+		t.Errorf("jump load %v, want %v", actions[3].JumpLoad, want)
+	}
+}
@@ -35,7 +35,7 @@ func getBestScoresPage(userId int, limit int, offset int) ([]Score, error) {
 	// Set the necessary headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", AuthToken.TokenType+" "+AuthToken.AccessToken)
+	req.Header.Set("Authorization", AuthToken().TokenType+" "+AuthToken().AccessToken)
 
 	done := GetToken()
 	Throttle()
@@ -71,7 +71,7 @@ type Score struct {
 	MaxCombo              int         `json:"max_combo"`
 	Mode                  string      `json:"mode"`
 	ModeInt               int         `json:"mode_int"`
-	Mods                  []string    `json:"mods"`
+	Mods                  []ScoreMod  `json:"mods"`
 	Passed                bool        `json:"passed"`
 	Perfect               bool        `json:"perfect"`
 	PP                    float64     `json:"pp"`
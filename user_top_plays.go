@@ -20,40 +20,34 @@ type Play struct {
 	Weight     float64
 	WeightedPP float64
 
-	Skills   Skills
-	OldIndex int
-	NewIndex int
+	Skills    Skills
+	Breakdown []SkillContribution // explains NewPP skill by skill
+	OldIndex  int
+	NewIndex  int
 
 	similaritySum float64
 }
 
-func EvalUserScores(userId int) ([]*Play, error) {
+// EvalUserScores recalculates the top plays of a user, leaving out plays on quarantined sets.
+func EvalUserScores(userId int, quarantined map[int]bool) ([]*Play, error) {
 	scores, err := GetBestScores(userId, 500)
 	if err != nil {
 		return nil, err
 	}
+	active := ActiveSkills()
 	wg := sync.WaitGroup{}
 	wg.Add(len(scores))
 	recalc := make([]*Play, len(scores))
 	for i, score := range scores {
 		Run(func() {
 			defer wg.Done()
-			mods := Modifiers{
-				Lazer: score.Score == 0,
-
-				Rate:       1.0,
-				Hardrock:   slices.Contains(score.Mods, "HR"),
-				Easy:       slices.Contains(score.Mods, "EZ"),
-				Hidden:     slices.Contains(score.Mods, "HD"),
-				Flashlight: slices.Contains(score.Mods, "FL"),
-				NoFail:     slices.Contains(score.Mods, "NF"),
-				SpunOut:    slices.Contains(score.Mods, "SO"),
-			}
-			if slices.Contains(score.Mods, "DT") {
-				mods.Rate = 1.5
+			if quarantined[score.Beatmap.BeatmapsetID] {
+				fmt.Println(i, score.BeatmapSet.Title, "skipped, set is quarantined")
+				return
 			}
-			if slices.Contains(score.Mods, "HT") {
-				mods.Rate = 0.75
+			mods, err := ParseModifiers(score.Score == 0, score.Mods)
+			if err != nil {
+				PanicF("ParseModifiers failed score id = %d, err = %s", score.ID, err.Error())
 			}
 
 			calculate := CalculateScore(
@@ -72,12 +66,14 @@ func EvalUserScores(userId int) ([]*Play, error) {
 				PrevPP:     score.PP,
 				NewPP:      calculate.Iter.PP,
 				Skills:     calculate.Iter.Skills,
+				Breakdown:  calculate.Iter.Skills.Breakdown(active),
 				OldIndex:   i,
 			}
 			fmt.Println(i, score.BeatmapSet.Title)
 		})
 	}
 	wg.Wait()
+	recalc = slices.DeleteFunc(recalc, func(play *Play) bool { return play == nil })
 	ret := make([]*Play, 0, len(recalc))
 	for range len(recalc) {
 		for _, score := range recalc {
@@ -92,18 +88,18 @@ func EvalUserScores(userId int) ([]*Play, error) {
 		max.NewIndex = len(ret)
 		ret = append(ret, max)
 		for _, score := range recalc {
-			score.similaritySum += Similarity(score.Skills, max.Skills)
+			score.similaritySum += Similarity(active, score.Skills, max.Skills)
 		}
 	}
 	return ret, nil
 }
 
-func Similarity(aSkills, bSkills Skills) float64 {
-	a, b := SkillsToVector(aSkills), SkillsToVector(bSkills)
+func Similarity(active []SkillDef, aSkills, bSkills Skills) float64 {
+	a, b := SkillsToVector(active, aSkills), SkillsToVector(active, bSkills)
 	dotProduct := 0.0
 	aSquare := 0.0
 	bSquare := 0.0
-	for i := range skillCount {
+	for i := range a {
 		dotProduct += a[i] * b[i]
 		aSquare += a[i] * a[i]
 		bSquare += b[i] * b[i]
@@ -121,7 +117,7 @@ func CalculateScore(
 	_, beatmap := OpenBeatmap(beatmapId)
 	mapConstants := GetBeatmapConstants(
 		beatmap,
-		mods,
+		mods.AdaptToMisses(countMisses),
 	)
 	actions, err := ConvertBeatmapToActions(
 		mapConstants,